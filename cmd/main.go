@@ -7,10 +7,12 @@ import (
 	"os/signal"
 	"syscall"
 
+	"pet-adoption-api/internal/auth"
 	"pet-adoption-api/internal/config"
 	"pet-adoption-api/internal/database"
 	"pet-adoption-api/internal/handlers"
 	"pet-adoption-api/internal/middleware"
+	"pet-adoption-api/internal/models"
 	"pet-adoption-api/internal/worker"
 
 	"github.com/gin-gonic/gin"
@@ -23,12 +25,19 @@ func main() {
 	// Connect DB
 	database.Connect()
 
-	// Init JWT managers for handlers + middleware
-	handlers.InitAuth()
-	middleware.InitAuthMiddleware()
+	// One JWT manager (built from the configured keyring) for handlers + middleware
+	jwtManager := auth.NewJWTManager(config.Keys, config.AccessTokenDuration)
+	handlers.InitAuth(jwtManager)
+	middleware.InitAuthMiddleware(jwtManager)
 
-	// Create adoption worker with buffered channel
+	// Optional single sign-on through an external OIDC provider
+	if config.OIDC != nil {
+		handlers.InitOIDC(auth.NewOIDCProvider(*config.OIDC, nil))
+	}
+
+	// Create adoption worker with buffered channels
 	aw := worker.NewAdoptionWorker(100)
+	aw.Notifier = worker.NotifierFromEnv(config.DevMode)
 
 	// Context for graceful shutdown (worker listens on this)
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
@@ -37,27 +46,73 @@ func main() {
 	// Start worker in background
 	go aw.Start(ctx)
 
+	// Carry out admin-approved personal data erasures
+	go worker.NewErasureJob(database.DB, aw.Events).Start(ctx)
+
+	// Expire stale adoption requests, reminding shelter staff beforehand
+	expiryJob := worker.NewAdoptionExpiryJob(database.DB, aw.Events, aw.Notifications)
+	expiryJob.DefaultTTL = config.AdoptionRequestTTL
+	expiryJob.ReminderLead = config.AdoptionReminderLead
+	go expiryJob.Start(ctx)
+
+	// Let expired pet holds lapse
+	go worker.NewPetHoldJob(database.DB, aw.Events).Start(ctx)
+
 	// Gin router
 	r := gin.Default()
 
 	// Give worker channel to handlers (for pushing events)
 	handlers.AdoptionEvents = aw.Events
+	handlers.Notifications = aw.Notifications
+
+	// Public keys for services verifying our tokens
+	r.GET("/.well-known/jwks.json", handlers.JWKS)
 
 	// Auth routes
-	auth := r.Group("/auth")
+	authRoutes := r.Group("/auth")
 	{
-		auth.POST("/register", handlers.Register)
-		auth.POST("/login", handlers.Login)
+		authRoutes.POST("/register", handlers.Register)
+		authRoutes.POST("/login", handlers.Login)
+		authRoutes.POST("/refresh", handlers.Refresh)
+		authRoutes.POST("/logout", handlers.Logout)
+		authRoutes.POST("/password/forgot", handlers.ForgotPassword)
+		authRoutes.POST("/password/reset", handlers.ResetPassword)
+		authRoutes.POST("/verify-email", handlers.VerifyEmail)
+		authRoutes.POST("/confirm-email", handlers.ConfirmEmailChange)
+		authRoutes.POST("/verify-email/resend", middleware.AuthMiddleware(), handlers.ResendVerificationEmail)
+		authRoutes.POST("/login/2fa", handlers.LoginWithTOTP)
+		authRoutes.POST("/2fa/enroll", middleware.MFAEnrollmentAuth(), handlers.EnrollTOTP)
+		authRoutes.POST("/2fa/confirm", middleware.MFAEnrollmentAuth(), handlers.ConfirmTOTP)
+		authRoutes.POST("/2fa/disable", middleware.AuthMiddleware(), handlers.DisableTOTP)
+		authRoutes.POST("/2fa/recovery-codes", middleware.AuthMiddleware(), handlers.RegenerateRecoveryCodes)
+		authRoutes.GET("/oidc/login", handlers.OIDCLogin)
+		authRoutes.GET("/oidc/callback", handlers.OIDCCallback)
 	}
 
-	// Pets routes
+	// Own account
+	meRoutes := r.Group("/me", middleware.AuthMiddleware())
+	{
+		meRoutes.GET("", handlers.GetMe)
+		meRoutes.PATCH("", handlers.UpdateMe)
+		meRoutes.DELETE("", handlers.DeleteMe)
+		meRoutes.POST("/password", handlers.ChangePassword)
+		meRoutes.GET("/export", handlers.ExportMyData)
+		meRoutes.POST("/erasure", handlers.RequestErasure)
+	}
+
+	// Pets routes (writes: shelter staff for their own shelters, or admin;
+	// shelter API keys with pets:write)
 	petRoutes := r.Group("/pets")
 	{
 		petRoutes.GET("/", handlers.GetPets)
 		petRoutes.GET("/:id", handlers.GetPetByID)
-		petRoutes.POST("/", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.CreatePet)
-		petRoutes.PUT("/:id", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.UpdatePet)
-		petRoutes.DELETE("/:id", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.DeletePet)
+		petRoutes.POST("/", middleware.AuthMiddleware(models.ScopePetsWrite), handlers.CreatePet)
+		petRoutes.PUT("/:id", middleware.AuthMiddleware(models.ScopePetsWrite), handlers.UpdatePet)
+		petRoutes.DELETE("/:id", middleware.AuthMiddleware(models.ScopePetsWrite), handlers.DeletePet)
+
+		// shelter staff reserve a pet for one applicant for a limited time
+		petRoutes.POST("/:id/hold", middleware.AuthMiddleware(models.ScopeAdoptionsWrite), handlers.PlacePetHold)
+		petRoutes.DELETE("/:id/hold", middleware.AuthMiddleware(models.ScopeAdoptionsWrite), handlers.ReleasePetHold)
 	}
 
 	// Shelters routes
@@ -65,26 +120,87 @@ func main() {
 	{
 		shelterRoutes.GET("/", handlers.GetShelters)
 		shelterRoutes.GET("/:id", handlers.GetShelterByID)
-		shelterRoutes.POST("/", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.CreateShelter)
+		shelterRoutes.POST("/", middleware.AuthMiddleware(), middleware.ShelterOnly(), handlers.CreateShelter)
 		shelterRoutes.PUT("/:id", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.UpdateShelter)
 		shelterRoutes.DELETE("/:id", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.DeleteShelter) // I've added this line
+
+		// owners and managers change their shelter's settings (checked in handler)
+		shelterRoutes.PATCH("/:id/settings", middleware.AuthMiddleware(), handlers.UpdateShelterSettings)
+
+		// staff memberships
+		shelterRoutes.GET("/:id/members", middleware.AuthMiddleware(), handlers.GetShelterMembers)
+		shelterRoutes.POST("/:id/members", middleware.AuthMiddleware(), handlers.InviteShelterMember)
+		shelterRoutes.POST("/:id/members/accept", middleware.AuthMiddleware(), handlers.AcceptShelterInvite)
+		shelterRoutes.DELETE("/:id/members/:userID", middleware.AuthMiddleware(), handlers.RemoveShelterMember)
+
+		// API keys for shelter integrations
+		shelterRoutes.GET("/:id/api-keys", middleware.AuthMiddleware(), handlers.GetAPIKeys)
+		shelterRoutes.POST("/:id/api-keys", middleware.AuthMiddleware(), handlers.CreateAPIKey)
+		shelterRoutes.DELETE("/:id/api-keys/:keyID", middleware.AuthMiddleware(), handlers.RevokeAPIKey)
+
+		// adoption questionnaires (the active one is public)
+		shelterRoutes.GET("/:id/questionnaire", handlers.GetActiveQuestionnaire)
+		shelterRoutes.GET("/:id/questionnaires", middleware.AuthMiddleware(), handlers.GetQuestionnaireVersions)
+		shelterRoutes.POST("/:id/questionnaires", middleware.AuthMiddleware(), handlers.CreateQuestionnaire)
+		shelterRoutes.DELETE("/:id/questionnaire", middleware.AuthMiddleware(), handlers.DisableQuestionnaire)
 	}
 
-	// Adoption routes (protected)
-	adoptionRoutes := r.Group("/adoptions", middleware.AuthMiddleware())
+	// Adoption routes (protected; shelter-side routes also take API keys)
+	adoptionRoutes := r.Group("/adoptions")
 	{
-		// user creates a request
-		adoptionRoutes.POST("/:petID/apply", handlers.ApplyForAdoption)
+		// user creates a request (verified email required); :id is the pet
+		// here, gin allows only one wildcard name per path segment
+		adoptionRoutes.POST("/:id/apply", middleware.AuthMiddleware(), middleware.VerifiedEmailOnly(), handlers.ApplyForAdoption)
 
 		// user sees only their own requests
-		adoptionRoutes.GET("/my", handlers.GetMyAdoptions)
+		adoptionRoutes.GET("/my", middleware.AuthMiddleware(), handlers.GetMyAdoptions)
+
+		// user withdraws their own pending request
+		adoptionRoutes.PATCH("/:id/cancel", middleware.AuthMiddleware(), handlers.CancelAdoption)
+
+		// applicant or shelter staff view one request (contact details for staff/admins only)
+		adoptionRoutes.GET("/:id", middleware.AuthMiddleware(models.ScopeAdoptionsRead), handlers.GetAdoptionRequest)
+
+		// shelter staff sees requests for their pets (membership checked in handler)
+		adoptionRoutes.GET("/shelter", middleware.AuthMiddleware(models.ScopeAdoptionsRead), handlers.GetShelterAdoptions)
+
+		// shelter owners/managers or admin approve or reject
+		adoptionRoutes.PATCH("/:id/approve", middleware.AuthMiddleware(models.ScopeAdoptionsWrite), handlers.ApproveAdoption)
+		adoptionRoutes.PATCH("/:id/reject", middleware.AuthMiddleware(models.ScopeAdoptionsWrite), handlers.RejectAdoption)
 
-		// shelter owner/admin sees requests for their pets
-		adoptionRoutes.GET("/shelter", middleware.ShelterOnly(), handlers.GetShelterAdoptions)
+		// conversation between the applicant and shelter staff
+		adoptionRoutes.GET("/:id/messages", middleware.AuthMiddleware(models.ScopeAdoptionsRead), handlers.GetAdoptionMessages)
+		adoptionRoutes.POST("/:id/messages", middleware.AuthMiddleware(models.ScopeAdoptionsWrite), handlers.PostAdoptionMessage)
+		adoptionRoutes.POST("/:id/messages/read", middleware.AuthMiddleware(), handlers.MarkAdoptionMessagesRead)
+		adoptionRoutes.GET("/:id/history", middleware.AuthMiddleware(models.ScopeAdoptionsRead), handlers.GetAdoptionHistory)
+		adoptionRoutes.POST("/:id/notes", middleware.AuthMiddleware(models.ScopeAdoptionsWrite), handlers.PostAdoptionNote)
+	}
 
-		// shelter owner/admin approve or reject
-		adoptionRoutes.PATCH("/:id/approve", middleware.ShelterOnly(), handlers.ApproveAdoption)
-		adoptionRoutes.PATCH("/:id/reject", middleware.ShelterOnly(), handlers.RejectAdoption)
+	// Shelter ownership applications (any logged-in user)
+	shelterAppRoutes := r.Group("/shelter-applications", middleware.AuthMiddleware())
+	{
+		shelterAppRoutes.POST("/", handlers.ApplyForShelterOwnership)
+		shelterAppRoutes.GET("/my", handlers.GetMyShelterApplications)
+	}
+
+	// Admin routes
+	adminRoutes := r.Group("/admin", middleware.AuthMiddleware(), middleware.AdminOnly())
+	{
+		adminRoutes.GET("/shelter-applications", handlers.ListShelterApplications)
+		adminRoutes.PATCH("/shelter-applications/:id/approve", handlers.ApproveShelterApplication)
+		adminRoutes.PATCH("/shelter-applications/:id/reject", handlers.RejectShelterApplication)
+		adminRoutes.GET("/users", handlers.ListUsers)
+		adminRoutes.GET("/users/:id", handlers.GetUser)
+		adminRoutes.POST("/users/:id/suspend", handlers.SuspendUser)
+		adminRoutes.POST("/users/:id/reactivate", handlers.ReactivateUser)
+		adminRoutes.PATCH("/users/:id/role", handlers.ChangeUserRole)
+		adminRoutes.POST("/users/:id/logout", handlers.ForceLogoutUser)
+		adminRoutes.POST("/users/:id/impersonate", handlers.ImpersonateUser)
+		adminRoutes.POST("/users/:id/unlock", handlers.UnlockUser)
+		adminRoutes.GET("/audit-logs", handlers.ListAuditLogs)
+		adminRoutes.GET("/erasure-requests", handlers.ListErasureRequests)
+		adminRoutes.PATCH("/erasure-requests/:id/approve", handlers.ApproveErasureRequest)
+		adminRoutes.PATCH("/erasure-requests/:id/reject", handlers.RejectErasureRequest)
 	}
 
 	// Read port from env, default 8080
@@ -1,54 +1,113 @@
 package auth
 
 import (
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var ErrInvalidToken = errors.New("invalid token")
+
+// Purposes for restricted, short-lived tokens. Regular access tokens have no
+// purpose and are rejected wherever a purpose token is expected (and vice versa).
+const (
+	PurposeMFAChallenge  = "mfa_challenge"
+	PurposeMFAEnrollment = "mfa_enrollment"
+)
+
 type JWTManager struct {
-	secretKey     string
+	keys          *Keyring
 	tokenDuration time.Duration
 }
 
 type UserClaims struct {
-	UserID uint   `json:"user_id"`
-	Role   string `json:"role"`
+	UserID    uint   `json:"user_id"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"`
+	// MFA is set when the session was opened with a second factor
+	MFA     bool   `json:"mfa,omitempty"`
+	Purpose string `json:"purpose,omitempty"`
+	// ImpersonatorID is the admin acting as UserID during support impersonation
+	ImpersonatorID uint `json:"imp,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewJWTManager(secret string, duration time.Duration) *JWTManager {
+func NewJWTManager(keys *Keyring, duration time.Duration) *JWTManager {
 	return &JWTManager{
-		secretKey:     secret,
+		keys:          keys,
 		tokenDuration: duration,
 	}
 }
 
-func (m *JWTManager) Generate(userID uint, role string) (string, error) {
-	claims := &UserClaims{
-		UserID: userID,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.tokenDuration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+// JWKS publishes the public verification keys.
+func (m *JWTManager) JWKS() JWKS {
+	return m.keys.JWKS()
+}
+
+// TokenDuration is how long access tokens issued by this manager stay valid.
+func (m *JWTManager) TokenDuration() time.Duration {
+	return m.tokenDuration
+}
+
+// Generate issues an access token. Claims should carry the session ID, so
+// the token can be revoked server-side by revoking the session.
+func (m *JWTManager) Generate(claims UserClaims) (string, error) {
+	claims.Purpose = ""
+	return m.sign(&claims, m.tokenDuration)
+}
+
+// GenerateImpersonation issues a short-lived access token that lets the
+// admin in claims.ImpersonatorID act as claims.UserID. Like regular access
+// tokens it is bound to a session.
+func (m *JWTManager) GenerateImpersonation(claims UserClaims, ttl time.Duration) (string, error) {
+	if claims.ImpersonatorID == 0 {
+		return "", errors.New("impersonation token without impersonator")
 	}
+	claims.Purpose = ""
+	return m.sign(&claims, ttl)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(m.secretKey))
+// GeneratePurpose issues a short-lived token that is only good for one step
+// of a flow (e.g. completing a 2FA login), never as an access token.
+func (m *JWTManager) GeneratePurpose(userID uint, role, purpose string, ttl time.Duration) (string, error) {
+	return m.sign(&UserClaims{UserID: userID, Role: role, Purpose: purpose}, ttl)
 }
 
+func (m *JWTManager) sign(claims *UserClaims, ttl time.Duration) (string, error) {
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}
+
+	key := m.keys.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signKey)
+}
+
+// Verify checks the token against the key named by its kid header. The
+// token's alg must match that key, so a public key can never be abused as
+// an HMAC secret.
 func (m *JWTManager) Verify(tokenStr string) (*UserClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &UserClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(m.secretKey), nil
-	})
+		kid, _ := t.Header["kid"].(string)
+		key, err := m.keys.Lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, ErrInvalidToken
+		}
+		return key.verifyKey, nil
+	}, jwt.WithValidMethods(m.keys.algorithms()))
 	if err != nil {
 		return nil, err
 	}
 
 	claims, ok := token.Claims.(*UserClaims)
 	if !ok || !token.Valid {
-		return nil, err
+		return nil, ErrInvalidToken
 	}
 
 	return claims, nil
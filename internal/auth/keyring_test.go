@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRSAKey(t *testing.T) *SigningKey {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewPrivateKey("", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newEd25519Key(t *testing.T) *SigningKey {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewPrivateKey("", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestJWTManager_AsymmetricRoundTrip(t *testing.T) {
+	for name, key := range map[string]*SigningKey{"RS256": newRSAKey(t), "EdDSA": newEd25519Key(t)} {
+		t.Run(name, func(t *testing.T) {
+			keys, err := NewKeyring(key)
+			assert.Nil(t, err)
+			m := NewJWTManager(keys, time.Minute)
+
+			token, err := m.Generate(UserClaims{UserID: 7, Role: "user", SessionID: "s1"})
+			assert.Nil(t, err)
+
+			parsed, _, _ := jwt.NewParser().ParseUnverified(token, &UserClaims{})
+			assert.Equal(t, name, parsed.Method.Alg())
+			assert.Equal(t, key.ID, parsed.Header["kid"])
+
+			claims, err := m.Verify(token)
+			assert.Nil(t, err)
+			assert.Equal(t, uint(7), claims.UserID)
+		})
+	}
+}
+
+// Tokens signed before a rotation stay valid while the old key is kept for verification
+func TestJWTManager_Rotation(t *testing.T) {
+	oldKey := newEd25519Key(t)
+	oldKeys, _ := NewKeyring(oldKey)
+	oldToken, _ := NewJWTManager(oldKeys, time.Minute).Generate(UserClaims{UserID: 1, Role: "user"})
+
+	retired, _ := NewPublicKey(oldKey.ID, oldKey.verifyKey)
+	newKeys, err := NewKeyring(newRSAKey(t), retired)
+	assert.Nil(t, err)
+	m := NewJWTManager(newKeys, time.Minute)
+
+	_, err = m.Verify(oldToken)
+	assert.Nil(t, err, "old token should still verify")
+
+	// once the old key is dropped, its tokens are rejected
+	dropped, _ := NewKeyring(newKeys.Active())
+	_, err = NewJWTManager(dropped, time.Minute).Verify(oldToken)
+	assert.NotNil(t, err)
+
+	jwks := m.JWKS()
+	assert.Len(t, jwks.Keys, 2)
+	assert.Equal(t, newKeys.Active().ID, jwks.Keys[0].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+	assert.Equal(t, "OKP", jwks.Keys[1].Kty)
+}
+
+// A token can't pick HS256 and use the published public key as the secret
+func TestJWTManager_RejectsAlgorithmConfusion(t *testing.T) {
+	key := newRSAKey(t)
+	keys, _ := NewKeyring(key)
+	m := NewJWTManager(keys, time.Minute)
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &UserClaims{UserID: 1, Role: "admin"})
+	forged.Header["kid"] = key.ID
+	tokenStr, _ := forged.SignedString([]byte("anything"))
+
+	_, err := m.Verify(tokenStr)
+	assert.NotNil(t, err)
+}
+
+func TestKeyring_HMACKeysAreNotPublished(t *testing.T) {
+	keys, _ := NewKeyring(NewHMACKey("hs", []byte("secret")))
+	assert.Empty(t, keys.JWKS().Keys)
+}
@@ -1,16 +1,191 @@
 package config
 
 import (
-    "log"
-    "os"
-    "github.com/joho/godotenv"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"pet-adoption-api/internal/auth"
+	"pet-adoption-api/internal/models"
+
+	"github.com/joho/godotenv"
 )
 
-var JwtKey []byte
+// AccessTokenDuration is how long access tokens are valid; clients renew
+// them with their refresh token.
+const AccessTokenDuration = 15 * time.Minute
+
+// Keys holds the JWT signing keyring built from the environment.
+var Keys *auth.Keyring
+
+// Require2FAForPrivileged forces admin and shelter accounts to sign in with
+// two-factor authentication (REQUIRE_2FA_FOR_PRIVILEGED=true).
+var Require2FAForPrivileged bool
+
+// DevMode is true when APP_ENV=dev. It enables insecure fallbacks such as
+// the built-in JWT secret.
+var DevMode bool
+
+// OIDC is the external identity provider for single sign-on, or nil when
+// OIDC login is disabled (see LoadOIDC).
+var OIDC *auth.OIDCConfig
+
+// AdoptionRequestTTL is how long adoption requests stay pending before they
+// expire, for shelters that don't set their own (ADOPTION_REQUEST_TTL_DAYS).
+var AdoptionRequestTTL = 30 * 24 * time.Hour
+
+// AdoptionReminderLead is how long before expiry shelter staff are reminded
+// of a pending request (ADOPTION_REQUEST_REMINDER_DAYS).
+var AdoptionReminderLead = 3 * 24 * time.Hour
+
+// MaxPendingAdoptions caps how many pending adoption requests a user may have
+// at once (MAX_PENDING_ADOPTIONS_PER_USER; 0 means no limit).
+var MaxPendingAdoptions = 5
 
 func LoadEnv() {
-    if err := godotenv.Load(); err != nil {
-        log.Println("No .env file found")
-    }
-    JwtKey = []byte(os.Getenv("JWT_SECRET"))
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	DevMode = os.Getenv("APP_ENV") == "dev"
+	Require2FAForPrivileged = os.Getenv("REQUIRE_2FA_FOR_PRIVILEGED") == "true"
+
+	keys, err := LoadKeyring()
+	if err != nil {
+		log.Fatalf("JWT signing key: %v", err)
+	}
+	Keys = keys
+
+	if AdoptionRequestTTL, err = envDays("ADOPTION_REQUEST_TTL_DAYS", AdoptionRequestTTL); err != nil {
+		log.Fatal(err)
+	}
+	if AdoptionReminderLead, err = envDays("ADOPTION_REQUEST_REMINDER_DAYS", AdoptionReminderLead); err != nil {
+		log.Fatal(err)
+	}
+
+	if v := os.Getenv("MAX_PENDING_ADOPTIONS_PER_USER"); v != "" {
+		if MaxPendingAdoptions, err = strconv.Atoi(v); err != nil || MaxPendingAdoptions < 0 {
+			log.Fatal("MAX_PENDING_ADOPTIONS_PER_USER must be a non-negative number")
+		}
+	}
+
+	oidc, err := LoadOIDC()
+	if err != nil {
+		log.Fatalf("OIDC: %v", err)
+	}
+	OIDC = oidc
+}
+
+// envDays reads a whole number of days, at most
+// models.MaxAdoptionRequestTTLDays, from the environment.
+func envDays(name string, fallback time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback, nil
+	}
+	days, err := strconv.Atoi(v)
+	if err != nil || days < 0 || days > models.MaxAdoptionRequestTTLDays {
+		return 0, fmt.Errorf("%s must be a number of days between 0 and %d", name, models.MaxAdoptionRequestTTLDays)
+	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+// LoadOIDC reads the identity provider settings. OIDC login is enabled by
+// setting OIDC_ISSUER, which then also needs OIDC_CLIENT_ID and
+// OIDC_REDIRECT_URI (our /auth/oidc/callback URL as registered with the
+// provider). OIDC_CLIENT_SECRET is only needed for confidential clients.
+func LoadOIDC() (*auth.OIDCConfig, error) {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		return nil, nil
+	}
+
+	cfg := &auth.OIDCConfig{
+		Issuer:       issuer,
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURI:  os.Getenv("OIDC_REDIRECT_URI"),
+	}
+	if cfg.ClientID == "" || cfg.RedirectURI == "" {
+		return nil, errors.New("OIDC_ISSUER is set but OIDC_CLIENT_ID or OIDC_REDIRECT_URI is missing")
+	}
+	return cfg, nil
+}
+
+// LoadKeyring builds the JWT keyring. The active key comes from
+// JWT_PRIVATE_KEY_FILE (RSA or Ed25519 PEM) or, failing that, JWT_SECRET
+// (HS256). JWT_PREVIOUS_KEY_FILES lists retired keys, as "path" or
+// "kid=path", that are still accepted for verification. JWT_PREVIOUS_SECRET
+// does the same for a retired HS256 secret (kid JWT_PREVIOUS_SECRET_KEY_ID,
+// default "hs256"), so moving from JWT_SECRET to a key file doesn't log
+// everyone out. Without any key configured, startup fails unless
+// APP_ENV=dev.
+func LoadKeyring() (*auth.Keyring, error) {
+	kid := os.Getenv("JWT_KEY_ID")
+
+	var active *auth.SigningKey
+	switch {
+	case os.Getenv("JWT_PRIVATE_KEY_FILE") != "":
+		key, err := readKeyFile(kid, os.Getenv("JWT_PRIVATE_KEY_FILE"))
+		if err != nil {
+			return nil, err
+		}
+		active = key
+	case os.Getenv("JWT_SECRET") != "":
+		if kid == "" {
+			kid = "hs256"
+		}
+		active = auth.NewHMACKey(kid, []byte(os.Getenv("JWT_SECRET")))
+	case DevMode:
+		log.Println("WARNING: no JWT key configured, using the insecure dev secret")
+		active = auth.NewHMACKey("dev", []byte("dev-secret"))
+	default:
+		return nil, errors.New("set JWT_PRIVATE_KEY_FILE or JWT_SECRET (or APP_ENV=dev for local development)")
+	}
+
+	var previous []*auth.SigningKey
+	for _, entry := range strings.Split(os.Getenv("JWT_PREVIOUS_KEY_FILES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, path := "", entry
+		if i := strings.Index(entry, "="); i >= 0 {
+			id, path = entry[:i], entry[i+1:]
+		}
+
+		key, err := readKeyFile(id, path)
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, key)
+	}
+
+	if secret := os.Getenv("JWT_PREVIOUS_SECRET"); secret != "" {
+		id := os.Getenv("JWT_PREVIOUS_SECRET_KEY_ID")
+		if id == "" {
+			id = "hs256"
+		}
+		previous = append(previous, auth.NewHMACKey(id, []byte(secret)))
+	}
+
+	return auth.NewKeyring(active, previous...)
+}
+
+func readKeyFile(kid, path string) (*auth.SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := auth.ParseKeyPEM(kid, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
 }
@@ -28,11 +28,86 @@ func Connect() {
     log.Println("Database connected")
 	
 
-	DB.AutoMigrate(
+	if err := Migrate(DB); err != nil {
+        log.Fatal("Failed to migrate database:", err)
+    }
+
+    fmt.Println("Database connected & migrated")
+}
+
+// Migrate creates or updates the tables for every model. It is shared by
+// Connect and the test setup so both always see the same schema.
+func Migrate(db *gorm.DB) error {
+    // the pending (user, pet) unique index can't be built over duplicates;
+    // clear them first, as migrations/000020 does
+    if err := cancelDuplicatePendingAdoptions(db); err != nil {
+        return err
+    }
+
+    // accounts created before email verification existed are trusted as-is,
+    // as in migrations/000008; only when the column is new, so later
+    // unverified accounts stay unverified
+    backfillVerified := db.Migrator().HasTable(&models.User{}) &&
+        !db.Migrator().HasColumn(&models.User{}, "email_verified_at")
+
+    err := db.AutoMigrate(
         &models.User{},
-        &models.Pet{},
         &models.Shelter{},
+        &models.Pet{},
+        &models.AdoptionRequest{},
+        &models.Session{},
+        &models.RefreshToken{},
+        &models.ShelterApplication{},
+        &models.UserToken{},
+        &models.RecoveryCode{},
+        &models.LoginThrottle{},
+        &models.ShelterMember{},
+        &models.APIKey{},
+        &models.UserIdentity{},
+        &models.OIDCLoginState{},
+        &models.ErasureRequest{},
+        &models.AuditLog{},
+        &models.PetHold{},
+        &models.Questionnaire{},
+        &models.AdoptionMessage{},
+        &models.AdoptionStatusChange{},
+        &models.AdoptionNote{},
     )
+    if err != nil {
+        return err
+    }
 
-    fmt.Println("Database connected & migrated")
+    if backfillVerified {
+        if err := db.Exec("UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL").Error; err != nil {
+            return err
+        }
+    }
+
+    // shelters created before memberships existed get their owner as member
+    return db.Exec(`
+        INSERT INTO shelter_members (shelter_id, user_id, role, status, accepted_at, created_at, updated_at)
+        SELECT s.id, s.owner_user_id, 'owner', 'active', s.created_at, s.created_at, s.created_at
+        FROM shelters s
+        WHERE NOT EXISTS (
+            SELECT 1 FROM shelter_members m WHERE m.shelter_id = s.id AND m.user_id = s.owner_user_id
+        )`).Error
+}
+
+// cancelDuplicatePendingAdoptions keeps only the oldest pending request per
+// applicant and pet. Older schemas may lack the table or status_reason.
+func cancelDuplicatePendingAdoptions(db *gorm.DB) error {
+    if !db.Migrator().HasTable(&models.AdoptionRequest{}) {
+        return nil
+    }
+
+    updates := map[string]interface{}{"status": models.AdoptionStatusCancelled}
+    if db.Migrator().HasColumn(&models.AdoptionRequest{}, "status_reason") {
+        updates["status_reason"] = "Duplicate request"
+    }
+    return db.Model(&models.AdoptionRequest{}).
+        Where("status = ?", models.AdoptionStatusPending).
+        Where("EXISTS (SELECT 1 FROM adoption_requests older WHERE older.user_id = adoption_requests.user_id "+
+            "AND older.pet_id = adoption_requests.pet_id AND older.status = ? AND older.id < adoption_requests.id)",
+            models.AdoptionStatusPending).
+        Updates(updates).Error
 }
@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"pet-adoption-api/internal/auth"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// impersonation tokens are short-lived and can't be refreshed
+const impersonationTokenDuration = 10 * time.Minute
+
+var (
+	errSelfAdministration = errors.New("admins cannot suspend or change the role of their own account")
+	errAlreadySuspended   = errors.New("user is already suspended")
+	errNotSuspended       = errors.New("user is not suspended")
+)
+
+type suspendUserRequest struct {
+	Reason string `json:"reason"`
+}
+
+type impersonateRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type changeRoleRequest struct {
+	Role   string `json:"role" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+// GET /admin/users?role=&email=&status=active|suspended&page=&page_size=
+// email matches any part of the address, case-insensitively.
+func ListUsers(c *gin.Context) {
+	page, pageSize, ok := parsePagination(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pagination"})
+		return
+	}
+
+	query := database.DB.Model(&models.User{})
+	if r := c.Query("role"); r != "" {
+		role, err := models.ParseRole(r)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown role"})
+			return
+		}
+		query = query.Where("role = ?", role)
+	}
+	if email := strings.TrimSpace(c.Query("email")); email != "" {
+		query = query.Where("LOWER(email) LIKE ?", "%"+strings.ToLower(email)+"%")
+	}
+	switch c.Query("status") {
+	case "":
+	case "active":
+		query = query.Where("suspended_at IS NULL")
+	case "suspended":
+		query = query.Where("suspended_at IS NOT NULL")
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active or suspended"})
+		return
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch users"})
+		return
+	}
+
+	var users []models.User
+	if err := query.Order("id").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users, "page": page, "page_size": pageSize, "total": total})
+}
+
+// GET /admin/users/:id
+func GetUser(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// POST /admin/users/:id/suspend
+// Also logs the user out everywhere.
+func SuspendUser(c *gin.Context) {
+	var req suspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		// reason is optional
+		req = suspendUserRequest{}
+	}
+
+	administerUser(c, func(tx *gorm.DB, adminID uint, user *models.User) error {
+		if user.ID == adminID {
+			return errSelfAdministration
+		}
+		if user.IsSuspended() {
+			return errAlreadySuspended
+		}
+
+		now := time.Now()
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"suspended_at":      now,
+			"suspension_reason": req.Reason,
+		}).Error; err != nil {
+			return err
+		}
+		if err := revokeUserSessions(tx, user.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx, adminID, models.AuditUserSuspended, user.ID, map[string]interface{}{"reason": req.Reason})
+	})
+}
+
+// POST /admin/users/:id/reactivate
+func ReactivateUser(c *gin.Context) {
+	administerUser(c, func(tx *gorm.DB, adminID uint, user *models.User) error {
+		if !user.IsSuspended() {
+			return errNotSuspended
+		}
+
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"suspended_at":      nil,
+			"suspension_reason": "",
+		}).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, adminID, models.AuditUserReactivated, user.ID, nil)
+	})
+}
+
+// PATCH /admin/users/:id/role
+// The user is logged out so the new role applies from their next login.
+func ChangeUserRole(c *gin.Context) {
+	var req changeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	role, err := models.ParseRole(req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown role"})
+		return
+	}
+
+	administerUser(c, func(tx *gorm.DB, adminID uint, user *models.User) error {
+		// keeps at least the acting admin around
+		if user.ID == adminID {
+			return errSelfAdministration
+		}
+
+		previous := user.Role
+		if previous == role {
+			return nil
+		}
+
+		if err := tx.Model(user).Update("role", role).Error; err != nil {
+			return err
+		}
+		if err := revokeUserSessions(tx, user.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx, adminID, models.AuditUserRoleChanged, user.ID, map[string]interface{}{
+			"from":   previous,
+			"to":     role,
+			"reason": req.Reason,
+		})
+	})
+}
+
+// POST /admin/users/:id/logout
+// Revokes every session of the user.
+func ForceLogoutUser(c *gin.Context) {
+	administerUser(c, func(tx *gorm.DB, adminID uint, user *models.User) error {
+		if err := revokeUserSessions(tx, user.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx, adminID, models.AuditUserLoggedOut, user.ID, nil)
+	})
+}
+
+// administerUser loads the :id user and runs action in a transaction,
+// then answers with the updated user or the matching error.
+func administerUser(c *gin.Context, action func(tx *gorm.DB, adminID uint, user *models.User) error) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var user models.User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, id).Error; err != nil {
+			return err
+		}
+		if err := action(tx, adminID, &user); err != nil {
+			return err
+		}
+		return tx.First(&user, id).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		case errors.Is(err, errSelfAdministration):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, errAlreadySuspended), errors.Is(err, errNotSuspended):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// POST /admin/users/:id/impersonate
+// Issues a read-only token to see the API as the user does. Every request
+// made with it is audited (see middleware.AuthMiddleware).
+func ImpersonateUser(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req impersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a reason is required"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if user.Role == models.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admins cannot be impersonated"})
+		return
+	}
+
+	// the impersonation counts as second-factor verified only if the admin's own session was
+	var adminSession models.Session
+	database.DB.Where("id = ?", c.GetString("sessionID")).First(&adminSession)
+
+	sessionID, err := auth.NewID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	var token string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		session := models.Session{ID: sessionID, UserID: user.ID, MFA: adminSession.MFA, ImpersonatorID: &adminID}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		var err error
+		token, err = jwtManager.GenerateImpersonation(auth.UserClaims{
+			UserID:         user.ID,
+			Role:           string(user.Role),
+			SessionID:      session.ID,
+			MFA:            session.MFA,
+			ImpersonatorID: adminID,
+		}, impersonationTokenDuration)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(tx, adminID, models.AuditImpersonationStarted, user.ID, map[string]interface{}{
+			"reason":     req.Reason,
+			"session_id": session.ID,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token":      token,
+		"expires_in": int64(impersonationTokenDuration.Seconds()),
+		"user": gin.H{
+			"id":    user.ID,
+			"name":  user.Name,
+			"email": user.Email,
+			"role":  user.Role,
+		},
+	})
+}
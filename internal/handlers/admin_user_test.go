@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"pet-adoption-api/internal/auth"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminUsers_ListAndFilter(t *testing.T) {
+	for _, email := range []string{"filter-a@example.org", "filter-b@example.org"} {
+		database.DB.Create(&models.User{Name: "Filter", Email: email, PasswordHash: "x", Role: models.RoleUser})
+	}
+
+	w := doJSON("GET", "/admin/users?email=FILTER-&page_size=1", adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var page struct {
+		Users []models.User `json:"users"`
+		Total int64         `json:"total"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &page)
+	assert.Equal(t, int64(2), page.Total)
+	if assert.Len(t, page.Users, 1) {
+		assert.Equal(t, "filter-a@example.org", page.Users[0].Email)
+	}
+
+	w = doJSON("GET", "/admin/users?role=admin", adminToken, nil)
+	json.Unmarshal(w.Body.Bytes(), &page)
+	for _, u := range page.Users {
+		assert.Equal(t, models.RoleAdmin, u.Role)
+	}
+
+	w = doJSON("GET", "/admin/users?role=superuser", adminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	_, pair := createProfileUser(t, "not-an-admin@test.com")
+	w = doJSON("GET", "/admin/users", pair.AccessToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// Suspension locks the user out at once; reactivation lets them back in
+func TestAdminUsers_SuspendAndReactivate(t *testing.T) {
+	user, pair := createProfileUser(t, "suspend-me@test.com")
+	userPath := "/admin/users/" + strconv.Itoa(int(user.ID))
+	database.DB.Create(&models.APIKey{ShelterID: 1, Name: "suspended", Prefix: "pak_suspende", KeyHash: auth.HashToken("pak_suspended"),
+		Scopes: []models.APIKeyScope{models.ScopeAdoptionsRead}, CreatedByID: user.ID})
+	w := doWithAPIKey("GET", "/adoptions/shelter", "pak_suspended", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON("POST", userPath+"/suspend", adminToken, gin.H{"reason": "spam"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON("POST", userPath+"/suspend", adminToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	assert.Equal(t, http.StatusForbidden, getMyAdoptions(pair.AccessToken))
+	w = doJSON("POST", "/auth/login", "", gin.H{"email": user.Email, "password": "password123"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// a token that slipped through is refused too
+	leftover, _ := startSession(user, false)
+	assert.Equal(t, http.StatusForbidden, getMyAdoptions(leftover.AccessToken))
+	// and so are the API keys they created
+	w = doWithAPIKey("GET", "/adoptions/shelter", "pak_suspended", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doJSON("GET", "/admin/users?status=suspended&email=suspend-me", adminToken, nil)
+	assert.Contains(t, w.Body.String(), "suspend-me@test.com")
+
+	w = doJSON("POST", userPath+"/reactivate", adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	loginAs(t, user.Email, "password123")
+	w = doWithAPIKey("GET", "/adoptions/shelter", "pak_suspended", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// admins can't lock themselves out
+	var admin models.User
+	database.DB.Where("email = ?", "admin@test.com").First(&admin)
+	w = doJSON("POST", "/admin/users/"+strconv.Itoa(int(admin.ID))+"/suspend", adminToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// Role changes log the user out and leave an audit entry
+func TestAdminUsers_RoleChangeAndForcedLogout(t *testing.T) {
+	user, pair := createProfileUser(t, "promote-me@test.com")
+	userPath := "/admin/users/" + strconv.Itoa(int(user.ID))
+
+	w := doJSON("PATCH", userPath+"/role", adminToken, gin.H{"role": "wizard"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON("PATCH", userPath+"/role", adminToken, gin.H{"role": "shelter", "reason": "runs a shelter"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, getMyAdoptions(pair.AccessToken))
+
+	resp := loginAs(t, user.Email, "password123")
+	assert.Equal(t, "shelter", resp["user"].(map[string]interface{})["role"])
+
+	w = doJSON("GET", "/admin/audit-logs?action=user.role_changed&target_user_id="+strconv.Itoa(int(user.ID)), adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var logs struct {
+		AuditLogs []models.AuditLog `json:"audit_logs"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &logs)
+	if assert.Len(t, logs.AuditLogs, 1) {
+		assert.Equal(t, "user", logs.AuditLogs[0].Details["from"])
+		assert.Equal(t, "shelter", logs.AuditLogs[0].Details["to"])
+	}
+
+	fresh := resp["token"].(string)
+	w = doJSON("POST", userPath+"/logout", adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, getMyAdoptions(fresh))
+}
+
+// Impersonation is read-only and every request shows up in the audit log
+func TestAdminUsers_Impersonation(t *testing.T) {
+	user, pair := createProfileUser(t, "impersonate-me@test.com")
+	userPath := "/admin/users/" + strconv.Itoa(int(user.ID))
+
+	w := doJSON("POST", userPath+"/impersonate", adminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON("POST", userPath+"/impersonate", pair.AccessToken, gin.H{"reason": "curious"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var admin models.User
+	database.DB.Where("email = ?", "admin@test.com").First(&admin)
+	w = doJSON("POST", "/admin/users/"+strconv.Itoa(int(admin.ID))+"/impersonate", adminToken, gin.H{"reason": "x"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doJSON("POST", userPath+"/impersonate", adminToken, gin.H{"reason": "ticket #42"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var resp struct {
+		Token string `json:"token"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	w = doJSON("GET", "/adoptions/my", resp.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strconv.Itoa(int(admin.ID)), w.Header().Get("X-Impersonated-By"))
+
+	w = doJSON("POST", "/me/password", resp.Token, gin.H{"current_password": "password123", "new_password": "hijacked"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	loginAs(t, user.Email, "password123")
+
+	target := "&target_user_id=" + strconv.Itoa(int(user.ID))
+	var logs struct {
+		AuditLogs []models.AuditLog `json:"audit_logs"`
+	}
+	for _, action := range []string{"impersonation.started", "impersonation.request", "impersonation.blocked"} {
+		w = doJSON("GET", "/admin/audit-logs?action="+action+target, adminToken, nil)
+		json.Unmarshal(w.Body.Bytes(), &logs)
+		if assert.Len(t, logs.AuditLogs, 1, action) {
+			assert.Equal(t, admin.ID, logs.AuditLogs[0].ActorID)
+		}
+	}
+
+	// logging the user out ends the impersonation too
+	doJSON("POST", userPath+"/logout", adminToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, getMyAdoptions(resp.Token))
+}
@@ -1,23 +1,51 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"pet-adoption-api/internal/config"
 	"pet-adoption-api/internal/database"
 	"pet-adoption-api/internal/models"
+	"pet-adoption-api/internal/repository"
 	"pet-adoption-api/internal/worker"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // This is set in main.go: handlers.AdoptionEvents = aw.Events
 var AdoptionEvents chan worker.AdoptionEvent
 
-// POST /adoptions/:petID/apply
+// publishAdoptionEvent hands the request's current state to the worker
+// without blocking; if the channel is full the event is skipped.
+func publishAdoptionEvent(ar models.AdoptionRequest, message string) {
+	if AdoptionEvents == nil {
+		return
+	}
+	select {
+	case AdoptionEvents <- worker.AdoptionEvent{
+		RequestID: ar.ID,
+		UserID:    ar.UserID,
+		PetID:     ar.PetID,
+		Status:    string(ar.Status),
+		Message:   message,
+	}:
+	default:
+		// channel full → skip
+	}
+}
+
+// POST /adoptions/:petID/apply (routed as /adoptions/:id/apply)
 type applyAdoptionRequest struct {
 	Message string `json:"message"`
+	// answers to the shelter's questionnaire, keyed by question id
+	Answers map[string]interface{} `json:"answers"`
 }
 
 func ApplyForAdoption(c *gin.Context) {
@@ -32,7 +60,7 @@ func ApplyForAdoption(c *gin.Context) {
 		return
 	}
 
-	petIDStr := c.Param("petID")
+	petIDStr := c.Param("id")
 	petIDInt, err := strconv.Atoi(petIDStr)
 	if err != nil || petIDInt <= 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pet id"})
@@ -46,6 +74,10 @@ func ApplyForAdoption(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "pet not found"})
 		return
 	}
+	if pet.Status == models.PetStatusReserved {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pet is reserved for another applicant"})
+		return
+	}
 	if pet.Status != models.PetStatusAvailable {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "pet is not available for adoption"})
 		return
@@ -54,41 +86,169 @@ func ApplyForAdoption(c *gin.Context) {
 	var reqBody applyAdoptionRequest
 	if err := c.ShouldBindJSON(&reqBody); err != nil {
 		// message is optional, but invalid JSON should still error
-		reqBody.Message = ""
+		reqBody = applyAdoptionRequest{}
+	}
+
+	// shelters with a questionnaire get their answers checked against it
+	qn, err := activeQuestionnaire(database.DB, pet.ShelterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create adoption request"})
+		return
+	}
+	var questionnaireID *uint
+	if qn != nil {
+		if problems := qn.ValidateAnswers(reqBody.Answers); problems != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":                 "questionnaire answers are incomplete or invalid",
+				"questionnaire_version": qn.Version,
+				"fields":                problems,
+			})
+			return
+		}
+		questionnaireID = &qn.ID
+	} else {
+		reqBody.Answers = nil
 	}
 
 	ar := models.AdoptionRequest{
-		UserID:    userID,
-		PetID:     petID,
-		Status:    models.AdoptionStatusPending,
-		Message:   reqBody.Message,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		UserID:          userID,
+		PetID:           petID,
+		Status:          models.AdoptionStatusPending,
+		Message:         reqBody.Message,
+		QuestionnaireID: questionnaireID,
+		Answers:         reqBody.Answers,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
-	if err := database.DB.Create(&ar).Error; err != nil {
+	// one pending request per pet, and only so many at once. The user row
+	// is locked so concurrent applications can't all pass the cap.
+	var pending []models.AdoptionRequest
+	var duplicate models.AdoptionRequest
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, userID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ? AND status = ?", userID, models.AdoptionStatusPending).
+			Order("created_at").
+			Find(&pending).Error; err != nil {
+			return err
+		}
+		for _, existing := range pending {
+			if existing.PetID == petID {
+				duplicate = existing
+				return errDuplicateAdoption
+			}
+		}
+		if limit := config.MaxPendingAdoptions; limit > 0 && len(pending) >= limit {
+			return errTooManyPendingAdoptions
+		}
+
+		return tx.Create(&ar).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errDuplicateAdoption):
+			respondDuplicateAdoption(c, duplicate)
+			return
+		case errors.Is(err, errTooManyPendingAdoptions):
+			ids := make([]uint, len(pending))
+			for i, existing := range pending {
+				ids[i] = existing.ID
+			}
+			c.JSON(http.StatusConflict, gin.H{
+				"error":               err.Error(),
+				"limit":               config.MaxPendingAdoptions,
+				"pending_request_ids": ids,
+			})
+			return
+		}
+
+		// the unique index catches a concurrent duplicate
+		var existing models.AdoptionRequest
+		if database.DB.Where("user_id = ? AND pet_id = ? AND status = ?", userID, petID, models.AdoptionStatusPending).
+			First(&existing).Error == nil {
+			respondDuplicateAdoption(c, existing)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create adoption request"})
 		return
 	}
 
 	// fire async event to worker (non-blocking)
-	if AdoptionEvents != nil {
-		select {
-		case AdoptionEvents <- worker.AdoptionEvent{
-			RequestID: ar.ID,
-			UserID:    ar.UserID,
-			PetID:     ar.PetID,
-			Status:    string(ar.Status),
-			Message:   "New adoption request created",
-		}:
-		default:
-			// channel full → skip silently
-		}
-	}
+	publishAdoptionEvent(ar, "New adoption request created")
 
 	c.JSON(http.StatusCreated, gin.H{"adoption_request": ar})
 }
 
+var (
+	errDuplicateAdoption       = errors.New("you already have a pending request for this pet")
+	errTooManyPendingAdoptions = errors.New("you have too many pending adoption requests; withdraw one or wait for a decision")
+)
+
+// respondDuplicateAdoption answers 409 pointing at the applicant's pending
+// request for the same pet.
+func respondDuplicateAdoption(c *gin.Context, existing models.AdoptionRequest) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error":               errDuplicateAdoption.Error(),
+		"adoption_request_id": existing.ID,
+	})
+}
+
+// GET /adoptions/:id
+// One request with summaries of its pet, shelter and applicant. The
+// applicant's contact details are only shown to the shelter's staff and
+// to admins.
+func GetAdoptionRequest(c *gin.Context) {
+	ar, fromShelter, ok := loadAdoptionParticipant(c, models.PermViewAdoptions)
+	if !ok {
+		return
+	}
+
+	var shelter models.Shelter
+	if err := database.DB.First(&shelter, ar.Pet.ShelterID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch shelter"})
+		return
+	}
+	// closed accounts are soft-deleted but still own their requests
+	var applicant models.User
+	if err := database.DB.Unscoped().First(&applicant, ar.UserID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch applicant"})
+		return
+	}
+
+	applicantSummary := gin.H{
+		"id":   applicant.ID,
+		"name": applicant.Name,
+	}
+	// fromShelter covers admins too (see canActForShelter)
+	if fromShelter {
+		applicantSummary["email"] = applicant.Email
+		applicantSummary["phone"] = applicant.Phone
+		applicantSummary["address"] = applicant.Address
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"adoption_request": ar,
+		"pet": gin.H{
+			"id":      ar.Pet.ID,
+			"name":    ar.Pet.Name,
+			"species": ar.Pet.Species,
+			"breed":   ar.Pet.Breed,
+			"age":     ar.Pet.Age,
+			"status":  ar.Pet.Status,
+		},
+		"shelter": gin.H{
+			"id":      shelter.ID,
+			"name":    shelter.Name,
+			"address": shelter.Address,
+			"phone":   shelter.Phone,
+		},
+		"applicant": applicantSummary,
+	})
+}
+
 // GET /adoptions/my
 func GetMyAdoptions(c *gin.Context) {
 	userIDVal, exists := c.Get("userID")
@@ -111,11 +271,15 @@ func GetMyAdoptions(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch adoption requests"})
 		return
 	}
+	if err := attachUnreadCounts(requests, false); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch adoption requests"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"adoption_requests": requests})
 }
 
-// GET /adoptions/shelter (ShelterOnly)
+// GET /adoptions/shelter (shelter staff)
 func GetShelterAdoptions(c *gin.Context) {
 	userIDVal, exists := c.Get("userID")
 	if !exists {
@@ -130,104 +294,222 @@ func GetShelterAdoptions(c *gin.Context) {
 
 	var requests []models.AdoptionRequest
 
-	// all requests for pets whose shelter owner is this user
-	if err := database.DB.
-		Joins("JOIN pets ON pets.id = adoption_requests.pet_id").
-		Joins("JOIN shelters ON shelters.id = pets.shelter_id").
-		Where("shelters.owner_user_id = ?", userID).
+	// all requests for pets of shelters where this user is active staff
+	// (or of the API key's own shelter)
+	query := database.DB.Joins("JOIN pets ON pets.id = adoption_requests.pet_id")
+	if key := currentAPIKey(c); key != nil {
+		query = query.Where("pets.shelter_id = ?", key.ShelterID)
+	} else {
+		query = query.Where("pets.shelter_id IN (?)", shelterIDsWithPermission(userID, models.PermViewAdoptions))
+	}
+
+	if err := query.
 		Preload("Pet").
 		Find(&requests).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch shelter adoption requests"})
 		return
 	}
+	if err := attachUnreadCounts(requests, true); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch shelter adoption requests"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"adoption_requests": requests})
 }
 
+type reviewAdoptionRequest struct {
+	Reason string `json:"reason"`
+}
+
 // PATCH /adoptions/:id/approve
 func ApproveAdoption(c *gin.Context) {
 	updateAdoptionStatus(c, models.AdoptionStatusApproved)
 }
 
 // PATCH /adoptions/:id/reject
+// The reason is required; the applicant sees it in the request's history.
 func RejectAdoption(c *gin.Context) {
 	updateAdoptionStatus(c, models.AdoptionStatusRejected)
 }
 
-// helper: approve / reject
-func updateAdoptionStatus(c *gin.Context, newStatus models.AdoptionStatus) {
-	userIDVal, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found in context"})
+var errNotYourAdoption = errors.New("not your adoption request")
+
+// PATCH /adoptions/:id/cancel
+// Lets the applicant withdraw a request while it is still pending.
+func CancelAdoption(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
-	userID, ok := userIDVal.(uint)
+
+	id, ok := parseIDParam(c, "id")
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user id type"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid adoption request id"})
+		return
+	}
+
+	var ar models.AdoptionRequest
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&ar, id).Error; err != nil {
+			return err
+		}
+		if ar.UserID != userID {
+			return errNotYourAdoption
+		}
+		if err := repository.TransitionAdoption(tx, &ar, models.AdoptionStatusCancelled, &userID, ""); err != nil {
+			return err
+		}
+		_, err := repository.ReleaseHold(tx, ar.ID)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "adoption request not found"})
+		case errors.Is(err, errNotYourAdoption):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrInvalidTransition):
+			database.DB.First(&ar, id)
+			respondInvalidTransition(c, ar, models.AdoptionStatusCancelled)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel adoption request"})
+		}
 		return
 	}
 
-	idStr := c.Param("id")
-	idInt, err := strconv.Atoi(idStr)
-	if err != nil || idInt <= 0 {
+	publishAdoptionEvent(ar, "Adoption request cancelled by the applicant")
+
+	c.JSON(http.StatusOK, gin.H{"adoption_request": ar})
+}
+
+var (
+	errPetNotAvailable = errors.New("pet is no longer available for adoption")
+	errPetReserved     = errors.New("pet is reserved for another applicant")
+)
+
+// helper: approve / reject. Runs in one transaction with the pet row locked,
+// so two approvals for the same pet can't both go through. Approving also
+// rejects the pet's other pending requests and tells their applicants.
+func updateAdoptionStatus(c *gin.Context, newStatus models.AdoptionStatus) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid adoption request id"})
 		return
 	}
-	id := uint(idInt)
+
+	// the body is optional when approving
+	var req reviewAdoptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if newStatus == models.AdoptionStatusRejected && req.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a reason is required to reject a request"})
+		return
+	}
 
 	var ar models.AdoptionRequest
 	if err := database.DB.
 		Preload("Pet").
-		Preload("Pet.Shelter").
 		First(&ar, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "adoption request not found"})
 		return
 	}
 
-	// check that current user is owner of the shelter for this pet OR admin
-	roleVal, _ := c.Get("role")
-	role, _ := roleVal.(string)
-
-	isOwner := ar.Pet.Shelter.OwnerUserID == userID
-	isAdmin := role == "admin"
-
-	if !isOwner && !isAdmin {
+	// check that current user may review adoptions for this pet's shelter (or is admin)
+	if !canActForShelter(c, userID, ar.Pet.ShelterID, models.PermReviewAdoptions) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to update this request"})
 		return
 	}
 
-	// update request status
-	ar.Status = newStatus
-	ar.UpdatedAt = time.Now()
+	var competing []models.AdoptionRequest
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if newStatus != models.AdoptionStatusApproved {
+			if err := repository.TransitionAdoption(tx, &ar, newStatus, &userID, req.Reason); err != nil {
+				return err
+			}
+			_, err := repository.ReleaseHold(tx, ar.ID)
+			return err
+		}
 
-	// if approved, set pet to adopted
-	if newStatus == models.AdoptionStatusApproved {
-		ar.Pet.Status = models.PetStatusAdopted
-		if err := database.DB.Save(&ar.Pet).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update pet status"})
-			return
+		var pet models.Pet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pet, ar.PetID).Error; err != nil {
+			return err
+		}
+		switch pet.Status {
+		case models.PetStatusAdopted:
+			return errPetNotAvailable
+		case models.PetStatusReserved:
+			hold, err := repository.ActiveHold(tx, pet.ID)
+			if err != nil {
+				return err
+			}
+			if hold != nil && hold.AdoptionRequestID != ar.ID {
+				return errPetReserved
+			}
 		}
-	}
 
-	if err := database.DB.Save(&ar).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update adoption request"})
+		if err := repository.TransitionAdoption(tx, &ar, newStatus, &userID, req.Reason); err != nil {
+			return err
+		}
+		if _, err := repository.ReleaseHold(tx, ar.ID); err != nil {
+			return err
+		}
+
+		pet.Status = models.PetStatusAdopted
+		pet.UpdatedAt = time.Now()
+		if err := tx.Save(&pet).Error; err != nil {
+			return err
+		}
+		ar.Pet = pet
+
+		var err error
+		competing, err = repository.RejectCompetingAdoptions(tx, ar, &userID,
+			"Another applicant has been approved to adopt "+pet.Name+".")
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidTransition):
+			// re-read in case someone else changed it in the meantime
+			database.DB.First(&ar, ar.ID)
+			respondInvalidTransition(c, ar, newStatus)
+		case errors.Is(err, errPetNotAvailable), errors.Is(err, errPetReserved):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update adoption request"})
+		}
 		return
 	}
 
 	// fire async event to worker (non-blocking)
-	if AdoptionEvents != nil {
-		select {
-		case AdoptionEvents <- worker.AdoptionEvent{
-			RequestID: ar.ID,
-			UserID:    ar.UserID,
-			PetID:     ar.PetID,
-			Status:    string(newStatus),
-			Message:   "Adoption request status updated",
-		}:
-		default:
-			// channel full → skip
-		}
+	publishAdoptionEvent(ar, "Adoption request status updated")
+
+	for _, other := range competing {
+		publishAdoptionEvent(other, "Adoption request rejected: another applicant was approved")
+		sendNotification(worker.Notification{
+			To:      other.User.Email,
+			Subject: "Your adoption request for " + ar.Pet.Name,
+			Body: "Thank you for your interest in " + ar.Pet.Name + ". " + other.StatusReason +
+				" Your request has been closed; other pets are still looking for a home.",
+		})
 	}
 
 	c.JSON(http.StatusOK, gin.H{"adoption_request": ar})
 }
+
+// respondInvalidTransition answers 409 for a status change the transition
+// table doesn't allow from the request's current status.
+func respondInvalidTransition(c *gin.Context, ar models.AdoptionRequest, to models.AdoptionStatus) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error":               "cannot change a " + string(ar.Status) + " request to " + string(to),
+		"status":              ar.Status,
+		"allowed_transitions": ar.Status.AllowedTransitions(),
+	})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The detail view shows contact details to shelter staff and admins only
+func TestGetAdoptionRequest_Visibility(t *testing.T) {
+	owner, ownerSession := createProfileUser(t, "detail-owner@test.com")
+	shelter := models.Shelter{Name: "Detailed Shelter", Phone: "555-0100", OwnerUserID: owner.ID}
+	database.DB.Create(&shelter)
+	pet := models.Pet{Name: "Detail", Species: "Rabbit", ShelterID: shelter.ID, Status: models.PetStatusAvailable}
+	database.DB.Create(&pet)
+	adopter, adopterSession := createProfileUser(t, "detail-adopter@test.com")
+	database.DB.Model(&adopter).Updates(map[string]interface{}{"phone": "555-0199", "address": "1 Burrow Lane"})
+	ar := models.AdoptionRequest{UserID: adopter.ID, PetID: pet.ID, Status: models.AdoptionStatusPending, Message: "I love rabbits"}
+	database.DB.Create(&ar)
+	path := "/adoptions/" + strconv.Itoa(int(ar.ID))
+
+	type detail struct {
+		AdoptionRequest models.AdoptionRequest `json:"adoption_request"`
+		Pet             map[string]interface{} `json:"pet"`
+		Shelter         map[string]interface{} `json:"shelter"`
+		Applicant       map[string]interface{} `json:"applicant"`
+	}
+
+	for _, token := range []string{ownerSession.AccessToken, adminToken} {
+		w := doJSON("GET", path, token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var d detail
+		json.Unmarshal(w.Body.Bytes(), &d)
+		assert.Equal(t, ar.ID, d.AdoptionRequest.ID)
+		assert.Equal(t, "Detail", d.Pet["name"])
+		assert.Equal(t, "Detailed Shelter", d.Shelter["name"])
+		assert.Equal(t, "detail-adopter@test.com", d.Applicant["email"])
+		assert.Equal(t, "555-0199", d.Applicant["phone"])
+		assert.Equal(t, "1 Burrow Lane", d.Applicant["address"])
+	}
+
+	w := doJSON("GET", path, adopterSession.AccessToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var d detail
+	json.Unmarshal(w.Body.Bytes(), &d)
+	assert.Equal(t, "I love rabbits", d.AdoptionRequest.Message)
+	assert.Equal(t, "555-0100", d.Shelter["phone"])
+	assert.NotContains(t, d.Applicant, "email")
+	assert.NotContains(t, d.Applicant, "phone")
+
+	_, strangerSession := createProfileUser(t, "detail-stranger@test.com")
+	w = doJSON("GET", path, strangerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doJSON("GET", "/adoptions/999999", adopterSession.AccessToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// the fixed routes still win over :id
+	w = doJSON("GET", "/adoptions/my", adopterSession.AccessToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxNoteLength = 5000
+
+type postNoteRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// applicantStatusChange is what the applicant sees of a history entry: no
+// staff identities.
+type applicantStatusChange struct {
+	FromStatus models.AdoptionStatus `json:"from_status"`
+	ToStatus   models.AdoptionStatus `json:"to_status"`
+	Reason     string                `json:"reason,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+}
+
+// GET /adoptions/:id/history
+// Staff get every status change with its actor plus their private notes;
+// the applicant gets the status changes only.
+func GetAdoptionHistory(c *gin.Context) {
+	ar, fromShelter, ok := loadAdoptionParticipant(c, models.PermViewAdoptions)
+	if !ok {
+		return
+	}
+
+	var changes []models.AdoptionStatusChange
+	if err := database.DB.Where("adoption_request_id = ?", ar.ID).
+		Order("created_at, id").
+		Find(&changes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch history"})
+		return
+	}
+
+	if !fromShelter {
+		history := make([]applicantStatusChange, len(changes))
+		for i, ch := range changes {
+			history[i] = applicantStatusChange{
+				FromStatus: ch.FromStatus,
+				ToStatus:   ch.ToStatus,
+				Reason:     ch.Reason,
+				CreatedAt:  ch.CreatedAt,
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"status_changes": history})
+		return
+	}
+
+	var notes []models.AdoptionNote
+	if err := database.DB.Where("adoption_request_id = ?", ar.ID).
+		Order("created_at, id").
+		Find(&notes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch notes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status_changes": changes, "notes": notes})
+}
+
+// POST /adoptions/:id/notes
+// Notes are for shelter staff only and can't be edited or deleted.
+func PostAdoptionNote(c *gin.Context) {
+	ar, fromShelter, ok := loadAdoptionParticipant(c, models.PermReviewAdoptions)
+	if !ok {
+		return
+	}
+	if !fromShelter {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only shelter staff can add notes"})
+		return
+	}
+
+	var req postNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Body) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "note body is required"})
+		return
+	}
+	if len(req.Body) > maxNoteLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "note is too long"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+	note := models.AdoptionNote{
+		AdoptionRequestID: ar.ID,
+		AuthorID:          userID,
+		Body:              req.Body,
+	}
+	if err := database.DB.Create(&note).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add note"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"note": note})
+}
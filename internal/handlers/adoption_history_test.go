@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"pet-adoption-api/internal/auth"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// Status changes are recorded with their actor; staff notes stay private
+func TestAdoptionHistory_StatusChangesAndNotes(t *testing.T) {
+	owner, ownerSession := createProfileUser(t, "history-owner@test.com")
+	shelter := models.Shelter{Name: "Recording Shelter", OwnerUserID: owner.ID}
+	database.DB.Create(&shelter)
+	pet := models.Pet{Name: "Well Documented", Species: "Dog", ShelterID: shelter.ID, Status: models.PetStatusAvailable}
+	database.DB.Create(&pet)
+	adopter, adopterSession := createProfileUser(t, "history-adopter@test.com")
+	ar := models.AdoptionRequest{UserID: adopter.ID, PetID: pet.ID, Status: models.AdoptionStatusPending}
+	database.DB.Create(&ar)
+	path := "/adoptions/" + strconv.Itoa(int(ar.ID))
+
+	w := doJSON("POST", path+"/notes", ownerSession.AccessToken, gin.H{"body": "References check out."})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w = doJSON("POST", path+"/notes", adopterSession.AccessToken, gin.H{"body": "Let me in"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// rejecting needs a reason
+	w = doJSON("PATCH", path+"/reject", ownerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON("PATCH", path+"/reject", ownerSession.AccessToken, gin.H{"reason": "  "})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON("PATCH", path+"/reject", ownerSession.AccessToken, gin.H{"reason": "We need a home with a garden."})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var staffView struct {
+		StatusChanges []models.AdoptionStatusChange `json:"status_changes"`
+		Notes         []models.AdoptionNote         `json:"notes"`
+	}
+	w = doJSON("GET", path+"/history", ownerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &staffView)
+	if assert.Len(t, staffView.StatusChanges, 2) {
+		first, last := staffView.StatusChanges[0], staffView.StatusChanges[1]
+		assert.Equal(t, models.AdoptionStatus(""), first.FromStatus)
+		assert.Equal(t, models.AdoptionStatusPending, first.ToStatus)
+		assert.Equal(t, models.AdoptionStatusPending, last.FromStatus)
+		assert.Equal(t, models.AdoptionStatusRejected, last.ToStatus)
+		assert.Equal(t, "We need a home with a garden.", last.Reason)
+		if assert.NotNil(t, last.ActorID) {
+			assert.Equal(t, owner.ID, *last.ActorID)
+		}
+	}
+	if assert.Len(t, staffView.Notes, 1) {
+		assert.Equal(t, "References check out.", staffView.Notes[0].Body)
+	}
+
+	w = doJSON("GET", path+"/history", adopterSession.AccessToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "We need a home with a garden.")
+	assert.NotContains(t, w.Body.String(), "References check out.")
+	assert.NotContains(t, w.Body.String(), "actor_id")
+	assert.NotContains(t, w.Body.String(), `"notes"`)
+
+	_, strangerSession := createProfileUser(t, "history-stranger@test.com")
+	w = doJSON("GET", path+"/history", strangerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// Notes take the reviewer permission: volunteers can't add them, write-only keys can
+func TestAdoptionNotes_ReviewersOnly(t *testing.T) {
+	owner, _ := createProfileUser(t, "notes-owner@test.com")
+	shelter := models.Shelter{Name: "Noting Shelter", OwnerUserID: owner.ID}
+	database.DB.Create(&shelter)
+	pet := models.Pet{Name: "Noted", Species: "Cat", ShelterID: shelter.ID, Status: models.PetStatusAvailable}
+	database.DB.Create(&pet)
+	adopter, _ := createProfileUser(t, "notes-adopter@test.com")
+	ar := models.AdoptionRequest{UserID: adopter.ID, PetID: pet.ID, Status: models.AdoptionStatusPending}
+	database.DB.Create(&ar)
+	path := "/adoptions/" + strconv.Itoa(int(ar.ID)) + "/notes"
+
+	volunteer, volunteerSession := createProfileUser(t, "notes-volunteer@test.com")
+	database.DB.Create(&models.ShelterMember{ShelterID: shelter.ID, UserID: volunteer.ID,
+		Role: models.ShelterRoleVolunteer, Status: models.MembershipActive})
+	w := doJSON("POST", path, volunteerSession.AccessToken, gin.H{"body": "Seems nice"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	database.DB.Create(&models.APIKey{ShelterID: shelter.ID, Name: "notes", Prefix: "pak_notesonl", KeyHash: auth.HashToken("pak_notesonly"),
+		Scopes: []models.APIKeyScope{models.ScopeAdoptionsWrite}, CreatedByID: owner.ID})
+	w = doWithAPIKey("POST", path, "pak_notesonly", gin.H{"body": "Imported from our CRM"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxMessageLength = 5000
+
+type postMessageRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// loadAdoptionParticipant loads the :id adoption request and works out which
+// side of it the caller is on: the applicant, or shelter staff holding perm.
+// On failure it has already written the error response.
+func loadAdoptionParticipant(c *gin.Context, perm models.ShelterPermission) (ar models.AdoptionRequest, fromShelter bool, ok bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return ar, false, false
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid adoption request id"})
+		return ar, false, false
+	}
+
+	if err := database.DB.Preload("Pet").First(&ar, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "adoption request not found"})
+		return ar, false, false
+	}
+
+	if currentAPIKey(c) == nil && ar.UserID == userID {
+		return ar, false, true
+	}
+	if canActForShelter(c, userID, ar.Pet.ShelterID, perm) {
+		return ar, true, true
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to access this adoption request"})
+	return ar, false, false
+}
+
+// GET /adoptions/:id/messages
+// Read-only; the caller marks messages as read with POST .../messages/read.
+func GetAdoptionMessages(c *gin.Context) {
+	ar, _, ok := loadAdoptionParticipant(c, models.PermViewAdoptions)
+	if !ok {
+		return
+	}
+
+	var messages []models.AdoptionMessage
+	if err := database.DB.Where("adoption_request_id = ?", ar.ID).
+		Order("created_at, id").
+		Find(&messages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"messages": messages})
+}
+
+// POST /adoptions/:id/messages/read
+// Marks the other side's messages as read. Only the applicant and actual
+// shelter staff count: API keys are refused by the route, and admins who
+// aren't members of the shelter can read the thread without marking it.
+func MarkAdoptionMessagesRead(c *gin.Context) {
+	ar, fromShelter, ok := loadAdoptionParticipant(c, models.PermViewAdoptions)
+	if !ok {
+		return
+	}
+
+	userID, _ := currentUserID(c)
+	// no role, so hasShelterPermission checks membership only
+	if fromShelter && !hasShelterPermission(userID, "", ar.Pet.ShelterID, models.PermViewAdoptions) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the applicant and the shelter's staff can mark messages as read"})
+		return
+	}
+
+	if err := database.DB.Model(&models.AdoptionMessage{}).
+		Where("adoption_request_id = ? AND from_shelter = ? AND read_at IS NULL", ar.ID, !fromShelter).
+		Update("read_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mark messages as read"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// POST /adoptions/:id/messages
+func PostAdoptionMessage(c *gin.Context) {
+	ar, fromShelter, ok := loadAdoptionParticipant(c, models.PermReviewAdoptions)
+	if !ok {
+		return
+	}
+
+	var req postMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Body) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "message body is required"})
+		return
+	}
+	if len(req.Body) > maxMessageLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "message is too long"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+	msg := models.AdoptionMessage{
+		AdoptionRequestID: ar.ID,
+		SenderID:          userID,
+		FromShelter:       fromShelter,
+		Body:              req.Body,
+	}
+	if err := database.DB.Create(&msg).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send message"})
+		return
+	}
+
+	if fromShelter {
+		publishAdoptionEvent(ar, "New message from the shelter")
+	} else {
+		publishAdoptionEvent(ar, "New message from the applicant")
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": msg})
+}
+
+// attachUnreadCounts fills in UnreadMessages on each request: messages from
+// the shelter for an applicant's view, from the applicant for staff.
+func attachUnreadCounts(requests []models.AdoptionRequest, forShelter bool) error {
+	if len(requests) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(requests))
+	for i, ar := range requests {
+		ids[i] = ar.ID
+	}
+
+	var rows []struct {
+		AdoptionRequestID uint
+		Unread            int64
+	}
+	if err := database.DB.Model(&models.AdoptionMessage{}).
+		Select("adoption_request_id, COUNT(*) AS unread").
+		Where("adoption_request_id IN ? AND from_shelter = ? AND read_at IS NULL", ids, !forShelter).
+		Group("adoption_request_id").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.AdoptionRequestID] = row.Unread
+	}
+	for i := range requests {
+		n := counts[requests[i].ID]
+		requests[i].UnreadMessages = &n
+	}
+	return nil
+}
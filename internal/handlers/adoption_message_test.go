@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// Applicant and shelter can talk on a request; unread counts show up in the lists
+func TestAdoptionMessages_ThreadAndUnreadCounts(t *testing.T) {
+	owner, ownerSession := createProfileUser(t, "messages-owner@test.com")
+	shelter := models.Shelter{Name: "Chatty Shelter", OwnerUserID: owner.ID}
+	database.DB.Create(&shelter)
+	pet := models.Pet{Name: "Talked About", Species: "Cat", ShelterID: shelter.ID, Status: models.PetStatusAvailable}
+	database.DB.Create(&pet)
+	adopter, adopterSession := createProfileUser(t, "messages-adopter@test.com")
+	_, strangerSession := createProfileUser(t, "messages-stranger@test.com")
+	ar := models.AdoptionRequest{UserID: adopter.ID, PetID: pet.ID, Status: models.AdoptionStatusPending}
+	database.DB.Create(&ar)
+	path := "/adoptions/" + strconv.Itoa(int(ar.ID)) + "/messages"
+
+	unread := func(listPath, token string) int64 {
+		w := doJSON("GET", listPath, token, nil)
+		var list struct {
+			AdoptionRequests []models.AdoptionRequest `json:"adoption_requests"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &list)
+		for _, r := range list.AdoptionRequests {
+			if r.ID == ar.ID && r.UnreadMessages != nil {
+				return *r.UnreadMessages
+			}
+		}
+		return -1
+	}
+
+	drainAdoptionEvents()
+	for _, body := range []string{"Do you have a garden?", "And how many hours are you away?"} {
+		w := doJSON("POST", path, ownerSession.AccessToken, gin.H{"body": body})
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+	assert.Len(t, drainAdoptionEvents(), 2)
+
+	w := doJSON("POST", path, strangerSession.AccessToken, gin.H{"body": "hi"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON("POST", path, adopterSession.AccessToken, gin.H{"body": "  "})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	assert.Equal(t, int64(2), unread("/adoptions/my", adopterSession.AccessToken))
+	assert.Equal(t, int64(0), unread("/adoptions/shelter", ownerSession.AccessToken))
+
+	// fetching the thread changes nothing; marking it read is explicit
+	w = doJSON("GET", path, adopterSession.AccessToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var thread struct {
+		Messages []models.AdoptionMessage `json:"messages"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &thread)
+	if assert.Len(t, thread.Messages, 2) {
+		assert.True(t, thread.Messages[0].FromShelter)
+		assert.Nil(t, thread.Messages[0].ReadAt)
+	}
+	assert.Equal(t, int64(2), unread("/adoptions/my", adopterSession.AccessToken))
+
+	// the shelter's messages are marked read, not one's own
+	w = doJSON("POST", path+"/read", adopterSession.AccessToken, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, int64(0), unread("/adoptions/my", adopterSession.AccessToken))
+
+	w = doJSON("POST", path, adopterSession.AccessToken, gin.H{"body": "Yes, a big one!"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, int64(1), unread("/adoptions/shelter", ownerSession.AccessToken))
+
+	// an admin outside the shelter can read along without marking anything
+	w = doJSON("GET", path, adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON("POST", path+"/read", adminToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, int64(1), unread("/adoptions/shelter", ownerSession.AccessToken))
+
+	w = doJSON("POST", path+"/read", ownerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = doJSON("GET", path, ownerSession.AccessToken, nil)
+	json.Unmarshal(w.Body.Bytes(), &thread)
+	if assert.Len(t, thread.Messages, 3) {
+		assert.NotNil(t, thread.Messages[0].ReadAt)
+		assert.NotNil(t, thread.Messages[2].ReadAt)
+	}
+	assert.Equal(t, int64(0), unread("/adoptions/shelter", ownerSession.AccessToken))
+}
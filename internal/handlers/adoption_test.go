@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"pet-adoption-api/internal/config"
 	"pet-adoption-api/internal/database"
 	"pet-adoption-api/internal/models"
+	"pet-adoption-api/internal/worker"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -35,3 +41,263 @@ func TestApplyForAdoption_CreatesRequest(t *testing.T) {
 	assert.Nil(t, err, "Adoption request should be found in the database")
 	assert.Equal(t, uint(petID), request.PetID)
 }
+
+// Only pending requests can be decided, and responses say what comes next
+func TestAdoptionStatus_Transitions(t *testing.T) {
+	pet := models.Pet{Name: "Transitions", Species: "Dog", ShelterID: 1, Status: models.PetStatusAvailable}
+	database.DB.Create(&pet)
+
+	w := doJSON("POST", "/adoptions/"+strconv.Itoa(int(pet.ID))+"/apply", adminToken, nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"allowed_transitions":["approved","rejected","cancelled","expired"]`)
+	var created struct {
+		AdoptionRequest models.AdoptionRequest `json:"adoption_request"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	path := "/adoptions/" + strconv.Itoa(int(created.AdoptionRequest.ID))
+
+	w = doJSON("PATCH", path+"/reject", adminToken, gin.H{"reason": "Not a match for this dog."})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"allowed_transitions":[]`)
+
+	for _, action := range []string{"/approve", "/reject"} {
+		w = doJSON("PATCH", path+action, adminToken, gin.H{"reason": "Second thoughts."})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	}
+	database.DB.First(&pet, pet.ID)
+	assert.Equal(t, models.PetStatusAvailable, pet.Status)
+}
+
+// drainAdoptionEvents empties the adoption event queue and returns what was in it
+func drainAdoptionEvents() []worker.AdoptionEvent {
+	var out []worker.AdoptionEvent
+	for {
+		select {
+		case evt := <-AdoptionEvents:
+			out = append(out, evt)
+		default:
+			return out
+		}
+	}
+}
+
+// Applicants can withdraw their own pending request, which frees a pet held for them
+func TestCancelAdoption(t *testing.T) {
+	user, pair := createProfileUser(t, "withdraw@test.com")
+	_, other := createProfileUser(t, "not-the-applicant@test.com")
+	pet := models.Pet{Name: "Withdrawn", Species: "Cat", ShelterID: 1, Status: models.PetStatusReserved}
+	database.DB.Create(&pet)
+	ar := models.AdoptionRequest{UserID: user.ID, PetID: pet.ID, Status: models.AdoptionStatusPending}
+	database.DB.Create(&ar)
+	database.DB.Create(&models.PetHold{PetID: pet.ID, AdoptionRequestID: ar.ID, PlacedByID: 1, ExpiresAt: time.Now().Add(time.Hour)})
+	path := "/adoptions/" + strconv.Itoa(int(ar.ID)) + "/cancel"
+
+	w := doJSON("PATCH", path, other.AccessToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	drainAdoptionEvents()
+	w = doJSON("PATCH", path, pair.AccessToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	events := drainAdoptionEvents()
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, ar.ID, events[0].RequestID)
+		assert.Equal(t, string(models.AdoptionStatusCancelled), events[0].Status)
+	}
+	database.DB.First(&pet, pet.ID)
+	assert.Equal(t, models.PetStatusAvailable, pet.Status)
+
+	w = doJSON("PATCH", path, pair.AccessToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+// Pending requests expire after the shelter's TTL; staff are reminded once beforehand
+func TestAdoptionExpiryJob(t *testing.T) {
+	owner, _ := createProfileUser(t, "expiry-owner@test.com")
+	shelter := models.Shelter{Name: "Slow Shelter", OwnerUserID: owner.ID, AdoptionRequestTTLDays: 5}
+	database.DB.Create(&shelter)
+	pets := make([]models.Pet, 3)
+	for i := range pets {
+		pets[i] = models.Pet{Name: "Waiting", Species: "Dog", ShelterID: shelter.ID, Status: models.PetStatusAvailable}
+	}
+	pets[2].Status = models.PetStatusReserved
+	database.DB.Create(&pets)
+	pet := pets[2]
+
+	day := 24 * time.Hour
+	fresh := models.AdoptionRequest{UserID: owner.ID, PetID: pets[0].ID, Status: models.AdoptionStatusPending, CreatedAt: time.Now().Add(-day)}
+	dueSoon := models.AdoptionRequest{UserID: owner.ID, PetID: pets[1].ID, Status: models.AdoptionStatusPending, CreatedAt: time.Now().Add(-3 * day)}
+	overdue := models.AdoptionRequest{UserID: owner.ID, PetID: pets[2].ID, Status: models.AdoptionStatusPending, CreatedAt: time.Now().Add(-6 * day)}
+	for _, ar := range []*models.AdoptionRequest{&fresh, &dueSoon, &overdue} {
+		database.DB.Create(ar)
+	}
+	database.DB.Create(&models.PetHold{PetID: pet.ID, AdoptionRequestID: overdue.ID, PlacedByID: owner.ID, ExpiresAt: time.Now().Add(day)})
+
+	events := make(chan worker.AdoptionEvent, 10)
+	notifications := make(chan worker.Notification, 10)
+	job := worker.NewAdoptionExpiryJob(database.DB, events, notifications)
+	// a second replica running at the same time must not repeat anything
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, job.RunOnce(context.Background()))
+	}
+
+	for ar, want := range map[*models.AdoptionRequest]models.AdoptionStatus{
+		&fresh:   models.AdoptionStatusPending,
+		&dueSoon: models.AdoptionStatusPending,
+		&overdue: models.AdoptionStatusExpired,
+	} {
+		database.DB.First(ar, ar.ID)
+		assert.Equal(t, want, ar.Status)
+	}
+	assert.Nil(t, fresh.ReminderSentAt)
+	assert.NotNil(t, dueSoon.ReminderSentAt)
+	database.DB.First(&pet, pet.ID)
+	assert.Equal(t, models.PetStatusAvailable, pet.Status)
+
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, overdue.ID, (<-events).RequestID)
+	}
+	if assert.Len(t, notifications, 1) {
+		n := <-notifications
+		assert.Equal(t, "expiry-owner@test.com", n.To)
+		assert.Contains(t, n.Body, "#"+strconv.Itoa(int(dueSoon.ID)))
+	}
+}
+
+// Reminders wait for a full queue instead of being lost, and a short TTL shortens the lead
+func TestAdoptionExpiryJob_ReminderDelivery(t *testing.T) {
+	owner, _ := createProfileUser(t, "expiry-quick@test.com")
+	shelter := models.Shelter{Name: "Quick Shelter", OwnerUserID: owner.ID, AdoptionRequestTTLDays: 2}
+	database.DB.Create(&shelter)
+	pets := []models.Pet{
+		{Name: "Just In", Species: "Cat", ShelterID: shelter.ID, Status: models.PetStatusAvailable},
+		{Name: "Halfway", Species: "Cat", ShelterID: shelter.ID, Status: models.PetStatusAvailable},
+	}
+	database.DB.Create(&pets)
+
+	// the default 3-day lead is longer than the 2-day TTL; only the second half counts
+	justIn := models.AdoptionRequest{UserID: owner.ID, PetID: pets[0].ID, Status: models.AdoptionStatusPending, CreatedAt: time.Now().Add(-time.Hour)}
+	halfway := models.AdoptionRequest{UserID: owner.ID, PetID: pets[1].ID, Status: models.AdoptionStatusPending, CreatedAt: time.Now().Add(-30 * time.Hour)}
+	database.DB.Create(&justIn)
+	database.DB.Create(&halfway)
+
+	// nobody drains this queue, so the reminder can't be delivered
+	full := make(chan worker.Notification)
+	job := worker.NewAdoptionExpiryJob(database.DB, nil, full)
+	job.NotifyTimeout = 10 * time.Millisecond
+	assert.NoError(t, job.RunOnce(context.Background()))
+	database.DB.First(&halfway, halfway.ID)
+	assert.Nil(t, halfway.ReminderSentAt)
+
+	notifications := make(chan worker.Notification, 10)
+	job.Notifications = notifications
+	assert.NoError(t, job.RunOnce(context.Background()))
+	database.DB.First(&halfway, halfway.ID)
+	database.DB.First(&justIn, justIn.ID)
+	assert.NotNil(t, halfway.ReminderSentAt)
+	assert.Nil(t, justIn.ReminderSentAt)
+	if assert.Len(t, notifications, 1) {
+		assert.Contains(t, (<-notifications).Body, "#"+strconv.Itoa(int(halfway.ID)))
+	}
+}
+
+// Approving one request closes the pet's other pending requests and tells their applicants
+func TestApproveAdoption_RejectsCompetingRequests(t *testing.T) {
+	pet := models.Pet{Name: "Popular", Species: "Dog", ShelterID: 1, Status: models.PetStatusAvailable}
+	database.DB.Create(&pet)
+
+	var requests []models.AdoptionRequest
+	for _, email := range []string{"popular-1@test.com", "popular-2@test.com", "popular-3@test.com"} {
+		user, _ := createProfileUser(t, email)
+		ar := models.AdoptionRequest{UserID: user.ID, PetID: pet.ID, Status: models.AdoptionStatusPending}
+		database.DB.Create(&ar)
+		requests = append(requests, ar)
+	}
+
+	drainNotifications()
+	w := doJSON("PATCH", "/adoptions/"+strconv.Itoa(int(requests[0].ID))+"/approve", adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	database.DB.First(&pet, pet.ID)
+	assert.Equal(t, models.PetStatusAdopted, pet.Status)
+	for _, ar := range requests[1:] {
+		database.DB.First(&ar, ar.ID)
+		assert.Equal(t, models.AdoptionStatusRejected, ar.Status)
+		assert.Contains(t, ar.StatusReason, "Another applicant")
+	}
+
+	var notified []string
+	for _, n := range drainNotifications() {
+		notified = append(notified, n.To)
+	}
+	assert.ElementsMatch(t, []string{"popular-2@test.com", "popular-3@test.com"}, notified)
+
+	w = doJSON("PATCH", "/adoptions/"+strconv.Itoa(int(requests[1].ID))+"/approve", adminToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+// A user can't apply twice for the same pet or hold too many pending requests
+func TestApplyForAdoption_DuplicatesAndCap(t *testing.T) {
+	user, pair := createProfileUser(t, "eager@test.com")
+	now := time.Now()
+	database.DB.Model(&user).Update("email_verified_at", &now)
+	defer func(limit int) { config.MaxPendingAdoptions = limit }(config.MaxPendingAdoptions)
+	config.MaxPendingAdoptions = 2
+
+	pets := make([]models.Pet, 3)
+	for i := range pets {
+		pets[i] = models.Pet{Name: "Eager", Species: "Cat", ShelterID: 1, Status: models.PetStatusAvailable}
+	}
+	database.DB.Create(&pets)
+	apply := func(pet models.Pet) *httptest.ResponseRecorder {
+		return doJSON("POST", "/adoptions/"+strconv.Itoa(int(pet.ID))+"/apply", pair.AccessToken, nil)
+	}
+
+	w := apply(pets[0])
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var first struct {
+		AdoptionRequest models.AdoptionRequest `json:"adoption_request"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &first)
+
+	w = apply(pets[0])
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var dup struct {
+		AdoptionRequestID uint `json:"adoption_request_id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &dup)
+	assert.Equal(t, first.AdoptionRequest.ID, dup.AdoptionRequestID)
+
+	// the index backs this up for requests created concurrently
+	assert.Error(t, database.DB.Create(&models.AdoptionRequest{UserID: user.ID, PetID: pets[0].ID, Status: models.AdoptionStatusPending}).Error)
+
+	assert.Equal(t, http.StatusCreated, apply(pets[1]).Code)
+	w = apply(pets[2])
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"limit":2`)
+
+	// withdrawing frees a slot, and the same pet can be applied for again
+	doJSON("PATCH", "/adoptions/"+strconv.Itoa(int(first.AdoptionRequest.ID))+"/cancel", pair.AccessToken, nil)
+	assert.Equal(t, http.StatusCreated, apply(pets[0]).Code)
+}
+
+// Migrate clears duplicate pending requests before building the unique index
+func TestMigrate_CancelsDuplicatePendingRequests(t *testing.T) {
+	assert.NoError(t, database.DB.Migrator().DropIndex(&models.AdoptionRequest{}, "idx_adoption_requests_pending_user_pet"))
+
+	user, _ := createProfileUser(t, "migrate-dupes@test.com")
+	pet := models.Pet{Name: "Twice Asked", Species: "Cat", ShelterID: 1, Status: models.PetStatusAvailable}
+	database.DB.Create(&pet)
+	first := models.AdoptionRequest{UserID: user.ID, PetID: pet.ID, Status: models.AdoptionStatusPending}
+	second := models.AdoptionRequest{UserID: user.ID, PetID: pet.ID, Status: models.AdoptionStatusPending}
+	database.DB.Create(&first)
+	database.DB.Create(&second)
+
+	assert.NoError(t, database.Migrate(database.DB))
+
+	database.DB.First(&first, first.ID)
+	database.DB.First(&second, second.ID)
+	assert.Equal(t, models.AdoptionStatusPending, first.Status)
+	assert.Equal(t, models.AdoptionStatusCancelled, second.Status)
+	assert.Equal(t, "Duplicate request", second.StatusReason)
+	assert.True(t, database.DB.Migrator().HasIndex(&models.AdoptionRequest{}, "idx_adoption_requests_pending_user_pet"))
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"pet-adoption-api/internal/auth"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// API keys look like "pak_<random>", so they are easy to spot in leaks.
+const apiKeyPrefix = "pak_"
+
+type createAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+}
+
+// POST /shelters/:id/api-keys
+func CreateAPIKey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	shelterID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shelter id"})
+		return
+	}
+
+	if !authorizeShelter(c, shelterID, models.PermManageAPIKeys) {
+		return
+	}
+
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	scopes := make([]models.APIKeyScope, 0, len(req.Scopes))
+	for _, s := range req.Scopes {
+		scope, err := models.ParseAPIKeyScope(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown scope " + s})
+			return
+		}
+		scopes = append(scopes, scope)
+	}
+
+	secret, _, err := auth.NewOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate API key"})
+		return
+	}
+	plain := apiKeyPrefix + secret
+
+	key := models.APIKey{
+		ShelterID:   shelterID,
+		Name:        req.Name,
+		Prefix:      plain[:12],
+		KeyHash:     auth.HashToken(plain),
+		Scopes:      scopes,
+		CreatedByID: userID,
+	}
+	if err := database.DB.Create(&key).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create API key"})
+		return
+	}
+
+	// the plain key is only ever shown here
+	c.JSON(http.StatusCreated, gin.H{"api_key": key, "key": plain})
+}
+
+// GET /shelters/:id/api-keys
+func GetAPIKeys(c *gin.Context) {
+	shelterID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shelter id"})
+		return
+	}
+
+	if !authorizeShelter(c, shelterID, models.PermManageAPIKeys) {
+		return
+	}
+
+	var keys []models.APIKey
+	if err := database.DB.Where("shelter_id = ?", shelterID).Order("created_at").Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// DELETE /shelters/:id/api-keys/:keyID
+func RevokeAPIKey(c *gin.Context) {
+	shelterID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shelter id"})
+		return
+	}
+
+	keyID, ok := parseIDParam(c, "keyID")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid API key id"})
+		return
+	}
+
+	if !authorizeShelter(c, shelterID, models.PermManageAPIKeys) {
+		return
+	}
+
+	res := database.DB.Model(&models.APIKey{}).
+		Where("id = ? AND shelter_id = ? AND revoked_at IS NULL", keyID, shelterID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke API key"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
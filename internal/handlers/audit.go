@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recordAudit appends an audit log entry, normally inside the transaction
+// that performs the action.
+func recordAudit(tx *gorm.DB, actorID uint, action models.AuditAction, targetUserID uint, details map[string]interface{}) error {
+	entry := models.AuditLog{
+		ActorID:      actorID,
+		Action:       action,
+		TargetUserID: &targetUserID,
+		Details:      details,
+	}
+	return tx.Create(&entry).Error
+}
+
+// GET /admin/audit-logs?action=&actor_id=&target_user_id=&page=&page_size=
+// Newest first.
+func ListAuditLogs(c *gin.Context) {
+	page, pageSize, ok := parsePagination(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pagination"})
+		return
+	}
+
+	query := database.DB.Model(&models.AuditLog{})
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	for _, filter := range []string{"actor_id", "target_user_id"} {
+		if v := c.Query(filter); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil || id <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + filter})
+				return
+			}
+			query = query.Where(filter+" = ?", id)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch audit logs"})
+		return
+	}
+
+	var entries []models.AuditLog
+	if err := query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch audit logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"audit_logs": entries, "page": page, "page_size": pageSize, "total": total})
+}
@@ -1,11 +1,11 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
-	"os"
-	"time"
 
 	"pet-adoption-api/internal/auth"
+	"pet-adoption-api/internal/config"
 	"pet-adoption-api/internal/database"
 	"pet-adoption-api/internal/models"
 
@@ -15,21 +15,17 @@ import (
 
 var jwtManager *auth.JWTManager
 
-// InitAuth should be called from main AFTER env is loaded.
-func InitAuth() {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		// dev default; in prod JWT_SECRET MUST be set
-		secret = "dev-secret"
-	}
-	jwtManager = auth.NewJWTManager(secret, 24*time.Hour)
+// InitAuth should be called from main with the manager built from
+// config.Keys, the same one the middleware verifies with.
+func InitAuth(m *auth.JWTManager) {
+	jwtManager = m
 }
 
 type registerRequest struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
-	Role     string `json:"role"` // optional; default = "user"
+	Role     string `json:"role"` // optional; only "user" is accepted
 }
 
 type loginRequest struct {
@@ -45,9 +41,18 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	role := models.RoleUser
+	// registration always yields a plain user; shelter owners go through
+	// a shelter application and admins are never self-service
 	if req.Role != "" {
-		role = models.Role(req.Role)
+		role, err := models.ParseRole(req.Role)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown role"})
+			return
+		}
+		if role != models.RoleUser {
+			c.JSON(http.StatusForbidden, gin.H{"error": "role cannot be chosen at registration"})
+			return
+		}
 	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
@@ -60,7 +65,7 @@ func Register(c *gin.Context) {
 		Name:         req.Name,
 		Email:        req.Email,
 		PasswordHash: string(hash),
-		Role:         role,
+		Role:         models.RoleUser,
 	}
 
 	if err := database.DB.Create(&user).Error; err != nil {
@@ -68,6 +73,11 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	if err := sendVerificationEmail(user); err != nil {
+		// the account exists; the user can ask for a new email later
+		log.Printf("failed to send verification email to user %d: %v", user.ID, err)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"user": gin.H{
 			"id":    user.ID,
@@ -75,6 +85,7 @@ func Register(c *gin.Context) {
 			"email": user.Email,
 			"role":  user.Role,
 		},
+		"message": "check your inbox to verify your email address",
 	})
 }
 
@@ -86,30 +97,83 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	accountKey := accountThrottleKey(req.Email)
+	ipKey := ipThrottleKey(c.ClientIP())
+	if !checkLoginThrottle(c, ipKey, accountKey) {
+		return
+	}
+
+	// unknown emails count as failures too, so probing them is throttled the same way
 	var user models.User
 	if err := database.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		recordFailedLogin(accountKey, ipKey)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		recordFailedLogin(accountKey, ipKey)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
 		return
 	}
 
-	token, err := jwtManager.Generate(user.ID, string(user.Role))
+	if completeLogin(c, user) {
+		clearLoginThrottle(accountKey)
+	}
+}
+
+// completeLogin finishes a login once the user's identity is established
+// (password or OIDC): it hands out a 2FA challenge or enrollment token when
+// needed, otherwise opens a session. It reports whether a session was started.
+func completeLogin(c *gin.Context, user models.User) bool {
+	if user.IsSuspended() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "account suspended"})
+		return false
+	}
+
+	// second step: POST /auth/login/2fa with the challenge token
+	if user.TOTPEnabledAt != nil {
+		challenge, err := jwtManager.GeneratePurpose(user.ID, string(user.Role), auth.PurposeMFAChallenge, mfaChallengeDuration)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+			return false
+		}
+		c.JSON(http.StatusOK, gin.H{"mfa_required": true, "challenge_token": challenge})
+		return false
+	}
+
+	// policy: privileged accounts must enroll before getting a session
+	if config.Require2FAForPrivileged && user.Role.IsPrivileged() {
+		enrollment, err := jwtManager.GeneratePurpose(user.ID, string(user.Role), auth.PurposeMFAEnrollment, mfaEnrollmentDuration)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+			return false
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":            "two-factor authentication must be set up for this account",
+			"enrollment_token": enrollment,
+		})
+		return false
+	}
+
+	pair, err := startSession(user, false)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
-		return
+		return false
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"user": gin.H{
-			"id":    user.ID,
-			"name":  user.Name,
-			"email": user.Email,
-			"role":  user.Role,
-		},
-	})
+	c.JSON(http.StatusOK, loginResponse(pair, user))
+	return true
+}
+
+// loginResponse is the body returned whenever a login completes.
+func loginResponse(pair tokenPair, user models.User) gin.H {
+	resp := pair.response()
+	resp["user"] = gin.H{
+		"id":    user.ID,
+		"name":  user.Name,
+		"email": user.Email,
+		"role":  user.Role,
+	}
+	return resp
 }
@@ -5,10 +5,21 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"pet-adoption-api/internal/auth"
+	"pet-adoption-api/internal/config"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"pet-adoption-api/internal/worker"
+	"regexp"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 // Note: No more setup function here. It uses the TestMain from shelter_test.go
@@ -67,3 +78,412 @@ func TestRegister_DuplicateEmail(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
+
+// loginAs logs in through the API and returns the decoded response
+func loginAs(t *testing.T, email, password string) map[string]interface{} {
+	body, _ := json.Marshal(gin.H{"email": email, "password": password})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	testRouter.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return response
+}
+
+func postRefreshToken(path, refreshToken string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(gin.H{"refresh_token": refreshToken})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	testRouter.ServeHTTP(w, req)
+	return w
+}
+
+func getMyAdoptions(token string) int {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/adoptions/my", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	testRouter.ServeHTTP(w, req)
+	return w.Code
+}
+
+// Refresh tokens rotate, and replaying a used one revokes the whole session
+func TestRefresh_RotationAndReuseDetection(t *testing.T) {
+	login := loginAs(t, "owner@test.com", "password")
+	firstRefresh, _ := login["refresh_token"].(string)
+	assert.NotEmpty(t, firstRefresh)
+
+	w := postRefreshToken("/auth/refresh", firstRefresh)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var rotated map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &rotated)
+	secondRefresh, _ := rotated["refresh_token"].(string)
+	newAccess, _ := rotated["token"].(string)
+	assert.NotEqual(t, firstRefresh, secondRefresh)
+	assert.Equal(t, http.StatusOK, getMyAdoptions(newAccess))
+
+	// replaying the first token is treated as theft
+	w = postRefreshToken("/auth/refresh", firstRefresh)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// ...and the rest of the family is revoked with it
+	w = postRefreshToken("/auth/refresh", secondRefresh)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, getMyAdoptions(newAccess))
+}
+
+// Logout revokes the session behind the access token
+func TestLogout_RevokesAccessToken(t *testing.T) {
+	login := loginAs(t, "owner@test.com", "password")
+	access, _ := login["token"].(string)
+	refresh, _ := login["refresh_token"].(string)
+
+	assert.Equal(t, http.StatusOK, getMyAdoptions(access))
+
+	w := postRefreshToken("/auth/logout", refresh)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, getMyAdoptions(access))
+}
+
+// Registration never grants an elevated or unknown role
+func TestRegister_RejectsRoleElevation(t *testing.T) {
+	cases := map[string]int{
+		"admin":     http.StatusForbidden,
+		"shelter":   http.StatusForbidden,
+		"superuser": http.StatusBadRequest,
+	}
+
+	for role, expected := range cases {
+		body, _ := json.Marshal(gin.H{
+			"name":     "Sneaky",
+			"email":    "sneaky-" + role + "@test.com",
+			"password": "password123",
+			"role":     role,
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/auth/register", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		testRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, expected, w.Code, "role %q", role)
+	}
+}
+
+// A user applies for shelter ownership and an admin approves it
+func TestShelterApplication_ApprovalPromotesUser(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	applicant := models.User{Name: "Applicant", Email: "applicant@test.com", PasswordHash: string(hash), Role: models.RoleUser}
+	database.DB.Create(&applicant)
+	pair, _ := startSession(applicant, false)
+
+	body, _ := json.Marshal(gin.H{"shelter_name": "Happy Tails", "address": "1 Main St"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/shelter-applications/", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+	testRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created map[string]models.ShelterApplication
+	json.Unmarshal(w.Body.Bytes(), &created)
+	appID := created["shelter_application"].ID
+
+	body, _ = json.Marshal(gin.H{"create_shelter": true})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PATCH", "/admin/shelter-applications/"+strconv.Itoa(int(appID))+"/approve", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	req.Header.Set("Content-Type", "application/json")
+	testRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var promoted models.User
+	database.DB.First(&promoted, applicant.ID)
+	assert.Equal(t, models.RoleShelter, promoted.Role)
+
+	var shelter models.Shelter
+	err := database.DB.Where("owner_user_id = ? AND name = ?", applicant.ID, "Happy Tails").First(&shelter).Error
+	assert.Nil(t, err, "approval should create the shelter")
+
+	// an application can only be reviewed once
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PATCH", "/admin/shelter-applications/"+strconv.Itoa(int(appID))+"/reject", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	testRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+// doJSON sends a JSON request through the test router, optionally authenticated
+func doJSON(method, path, token string, payload interface{}) *httptest.ResponseRecorder {
+	var body *bytes.Buffer
+	if payload != nil {
+		b, _ := json.Marshal(payload)
+		body = bytes.NewBuffer(b)
+	} else {
+		body = bytes.NewBuffer(nil)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, body)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	testRouter.ServeHTTP(w, req)
+	return w
+}
+
+// drainNotifications empties the notification queue and returns what was in it
+func drainNotifications() []worker.Notification {
+	var out []worker.Notification
+	for {
+		select {
+		case n := <-Notifications:
+			out = append(out, n)
+		default:
+			return out
+		}
+	}
+}
+
+var mailedTokenPattern = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+// Forgot + reset changes the password, burns the token and logs out old sessions
+func TestPasswordReset_Flow(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.DefaultCost)
+	user := models.User{Name: "Forgetful", Email: "forgetful@test.com", PasswordHash: string(hash), Role: models.RoleUser}
+	database.DB.Create(&user)
+	oldSession, _ := startSession(user, false)
+
+	drainNotifications()
+	w := doJSON("POST", "/auth/password/forgot", "", gin.H{"email": user.Email})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	// unknown emails get the same answer and no mail
+	w = doJSON("POST", "/auth/password/forgot", "", gin.H{"email": "nobody@test.com"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	sent := drainNotifications()
+	if assert.Len(t, sent, 1) {
+		assert.Equal(t, user.Email, sent[0].To)
+	}
+	match := mailedTokenPattern.FindStringSubmatch(sent[0].Body)
+	if !assert.Len(t, match, 2, "reset link should contain a token") {
+		return
+	}
+	token := match[1]
+
+	w = doJSON("POST", "/auth/password/reset", "", gin.H{"token": token, "password": "new-password"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// single use
+	w = doJSON("POST", "/auth/password/reset", "", gin.H{"token": token, "password": "another-one"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// existing JWTs are dead, the new password works
+	assert.Equal(t, http.StatusUnauthorized, getMyAdoptions(oldSession.AccessToken))
+	loginAs(t, user.Email, "new-password")
+}
+
+// Unverified accounts can't apply for adoption until they follow the emailed link
+func TestEmailVerification_GatesAdoption(t *testing.T) {
+	var shelter models.Shelter
+	database.DB.First(&shelter)
+	pet := models.Pet{Name: "Verify Pet", Species: "Cat", ShelterID: shelter.ID, Status: models.PetStatusAvailable}
+	database.DB.Create(&pet)
+	applyPath := "/adoptions/" + strconv.Itoa(int(pet.ID)) + "/apply"
+
+	drainNotifications()
+	w := doJSON("POST", "/auth/register", "", gin.H{"name": "New Adopter", "email": "verify@test.com", "password": "password123"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	sent := drainNotifications()
+	if !assert.Len(t, sent, 1) {
+		return
+	}
+	match := mailedTokenPattern.FindStringSubmatch(sent[0].Body)
+	if !assert.Len(t, match, 2, "verification mail should contain a token") {
+		return
+	}
+
+	login := loginAs(t, "verify@test.com", "password123")
+	token, _ := login["token"].(string)
+
+	// browsing still works, applying does not
+	assert.Equal(t, http.StatusOK, getMyAdoptions(token))
+	w = doJSON("POST", applyPath, token, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// resending right away is throttled
+	w = doJSON("POST", "/auth/verify-email/resend", token, nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	w = doJSON("POST", "/auth/verify-email", "", gin.H{"token": match[1]})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON("POST", applyPath, token, nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = doJSON("POST", "/auth/verify-email/resend", token, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+// Accounts that predate email verification are trusted when Migrate adds the column
+func TestMigrate_BackfillsEmailVerifiedAt(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:legacy-users?mode=memory&cache=shared"), &gorm.Config{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	created := time.Now().Add(-48 * time.Hour).UTC().Truncate(time.Second)
+	assert.NoError(t, db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, email TEXT NOT NULL,
+		password_hash TEXT NOT NULL, role VARCHAR(20) NOT NULL, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`).Error)
+	assert.NoError(t, db.Exec("INSERT INTO users (name, email, password_hash, role, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		"Old Timer", "legacy@test.com", "x", models.RoleUser, created, created).Error)
+
+	assert.NoError(t, database.Migrate(db))
+	var legacy models.User
+	db.Where("email = ?", "legacy@test.com").First(&legacy)
+	if assert.NotNil(t, legacy.EmailVerifiedAt) {
+		assert.True(t, created.Equal(legacy.EmailVerifiedAt.UTC()))
+	}
+
+	// later sign-ups still have to verify, even after another Migrate
+	fresh := models.User{Name: "Newcomer", Email: "newcomer@test.com", PasswordHash: "x", Role: models.RoleUser}
+	db.Create(&fresh)
+	assert.NoError(t, database.Migrate(db))
+	db.First(&fresh, fresh.ID)
+	assert.Nil(t, fresh.EmailVerifiedAt)
+}
+
+// Enrolling in TOTP turns login into a two-step flow
+func TestTwoFactor_EnrollAndLogin(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	user := models.User{Name: "Careful", Email: "totp@test.com", PasswordHash: string(hash), Role: models.RoleShelter}
+	database.DB.Create(&user)
+
+	login := loginAs(t, user.Email, "password")
+	token, _ := login["token"].(string)
+
+	w := doJSON("POST", "/auth/2fa/enroll", token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var enroll map[string]string
+	json.Unmarshal(w.Body.Bytes(), &enroll)
+	secret := enroll["secret"]
+	assert.Contains(t, enroll["otpauth_uri"], "otpauth://totp/")
+
+	code, _ := auth.TOTPCode(secret, time.Now())
+	w = doJSON("POST", "/auth/2fa/confirm", token, gin.H{"code": code})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var confirm map[string][]string
+	json.Unmarshal(w.Body.Bytes(), &confirm)
+	assert.Len(t, confirm["recovery_codes"], recoveryCodeCount)
+
+	// password alone now only yields a challenge
+	login = loginAs(t, user.Email, "password")
+	assert.Equal(t, true, login["mfa_required"])
+	assert.NotContains(t, login, "token")
+	challenge, _ := login["challenge_token"].(string)
+
+	// the challenge token is not an access token
+	assert.Equal(t, http.StatusUnauthorized, getMyAdoptions(challenge))
+
+	// the code used to confirm can't be replayed
+	w = doJSON("POST", "/auth/login/2fa", "", gin.H{"challenge_token": challenge, "code": code})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	next, _ := auth.TOTPCode(secret, time.Now().Add(30*time.Second))
+	w = doJSON("POST", "/auth/login/2fa", "", gin.H{"challenge_token": challenge, "code": next})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// recovery codes work exactly once
+	recovery := confirm["recovery_codes"][0]
+	w = doJSON("POST", "/auth/login/2fa", "", gin.H{"challenge_token": challenge, "recovery_code": recovery})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON("POST", "/auth/login/2fa", "", gin.H{"challenge_token": challenge, "recovery_code": recovery})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// With the policy on, privileged accounts must enroll before getting a session
+func TestTwoFactor_PolicyForcesPrivilegedEnrollment(t *testing.T) {
+	config.Require2FAForPrivileged = true
+	defer func() { config.Require2FAForPrivileged = false }()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	user := models.User{Name: "Policy Admin", Email: "policy-admin@test.com", PasswordHash: string(hash), Role: models.RoleAdmin}
+	database.DB.Create(&user)
+
+	// sessions opened without 2FA are refused
+	old, _ := startSession(user, false)
+	assert.Equal(t, http.StatusForbidden, getMyAdoptions(old.AccessToken))
+
+	w := doJSON("POST", "/auth/login", "", gin.H{"email": user.Email, "password": "password"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var gate map[string]string
+	json.Unmarshal(w.Body.Bytes(), &gate)
+	enrollment := gate["enrollment_token"]
+	assert.NotEmpty(t, enrollment)
+
+	// the enrollment token only opens the enrollment endpoints
+	assert.Equal(t, http.StatusUnauthorized, getMyAdoptions(enrollment))
+
+	w = doJSON("POST", "/auth/2fa/enroll", enrollment, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var enroll map[string]string
+	json.Unmarshal(w.Body.Bytes(), &enroll)
+
+	code, _ := auth.TOTPCode(enroll["secret"], time.Now())
+	w = doJSON("POST", "/auth/2fa/confirm", enrollment, gin.H{"code": code})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var confirmed map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &confirmed)
+	access, _ := confirmed["token"].(string)
+	assert.Equal(t, http.StatusOK, getMyAdoptions(access))
+}
+
+// Repeated failures trigger backoff; an admin can lift it
+func TestLoginThrottle_BackoffAndUnlock(t *testing.T) {
+	defer database.DB.Where("1 = 1").Delete(&models.LoginThrottle{})
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	user := models.User{Name: "Target", Email: "target@test.com", PasswordHash: string(hash), Role: models.RoleShelter}
+	database.DB.Create(&user)
+
+	for i := 0; i < accountLoginPolicy.FreeAttempts; i++ {
+		w := doJSON("POST", "/auth/login", "", gin.H{"email": user.Email, "password": "wrong"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	// even the right password is refused during backoff
+	w := doJSON("POST", "/auth/login", "", gin.H{"email": user.Email, "password": "password"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	w = doJSON("POST", "/admin/users/"+strconv.Itoa(int(user.ID))+"/unlock", adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	loginAs(t, user.Email, "password")
+}
+
+// Hitting the lockout threshold answers 423 until it expires
+func TestLoginThrottle_Lockout(t *testing.T) {
+	defer database.DB.Where("1 = 1").Delete(&models.LoginThrottle{})
+
+	key := accountThrottleKey("locked@test.com")
+	for i := 0; i < accountLoginPolicy.LockoutAfter; i++ {
+		recordLoginFailure(key, accountLoginPolicy)
+	}
+
+	w := doJSON("POST", "/auth/login", "", gin.H{"email": "locked@test.com", "password": "whatever"})
+	assert.Equal(t, http.StatusLocked, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"pet-adoption-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserID returns the authenticated user's ID stored by
+// middleware.AuthMiddleware. On failure it has already written the error
+// response, so callers only need to return.
+func currentUserID(c *gin.Context) (uint, bool) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found in context"})
+		return 0, false
+	}
+	userID, ok := userIDVal.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user id type"})
+		return 0, false
+	}
+	return userID, true
+}
+
+// currentRole returns the authenticated user's role ("" if missing).
+func currentRole(c *gin.Context) models.Role {
+	roleVal, _ := c.Get("role")
+	role, _ := roleVal.(string)
+	return models.Role(role)
+}
+
+// currentAPIKey returns the API key the request authenticated with, or nil
+// for regular user tokens.
+func currentAPIKey(c *gin.Context) *models.APIKey {
+	keyVal, _ := c.Get("apiKey")
+	key, _ := keyVal.(*models.APIKey)
+	return key
+}
+
+// parseIDParam reads a positive numeric path parameter.
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// parsePagination reads ?page (1-based) and ?page_size, applying defaults
+// and capping the page size.
+func parsePagination(c *gin.Context) (page, pageSize int, ok bool) {
+	page, pageSize = 1, defaultPageSize
+	var err error
+	if v := c.Query("page"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil || page < 1 {
+			return 0, 0, false
+		}
+	}
+	if v := c.Query("page_size"); v != "" {
+		if pageSize, err = strconv.Atoi(v); err != nil || pageSize < 1 {
+			return 0, 0, false
+		}
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return page, pageSize, true
+}
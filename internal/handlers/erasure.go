@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"pet-adoption-api/internal/repository"
+	"pet-adoption-api/internal/worker"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errErasureNotPending = errors.New("erasure request is not pending")
+
+type erasureRequestBody struct {
+	Reason string `json:"reason"`
+}
+
+type reviewErasureRequestBody struct {
+	ReviewNote string `json:"review_note"`
+}
+
+// POST /me/erasure
+// Asks for the account's personal data to be erased; an admin approves it.
+func RequestErasure(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req erasureRequestBody
+	if err := c.ShouldBindJSON(&req); err != nil {
+		// reason is optional
+		req = erasureRequestBody{}
+	}
+
+	var open int64
+	database.DB.Model(&models.ErasureRequest{}).
+		Where("user_id = ? AND status IN ?", userID, []models.ErasureRequestStatus{models.ErasureRequestPending, models.ErasureRequestApproved}).
+		Count(&open)
+	if open > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "you already have an open erasure request"})
+		return
+	}
+
+	er := models.ErasureRequest{
+		UserID: userID,
+		Reason: req.Reason,
+		Status: models.ErasureRequestPending,
+	}
+	if err := database.DB.Create(&er).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create erasure request"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"erasure_request": er})
+}
+
+// GET /admin/erasure-requests?status=pending
+func ListErasureRequests(c *gin.Context) {
+	var requests []models.ErasureRequest
+
+	query := database.DB.Model(&models.ErasureRequest{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Order("created_at").Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch erasure requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"erasure_requests": requests})
+}
+
+// PATCH /admin/erasure-requests/:id/approve
+func ApproveErasureRequest(c *gin.Context) {
+	reviewErasureRequest(c, models.ErasureRequestApproved)
+}
+
+// PATCH /admin/erasure-requests/:id/reject
+func RejectErasureRequest(c *gin.Context) {
+	reviewErasureRequest(c, models.ErasureRequestRejected)
+}
+
+// helper: approve / reject. Approved requests are carried out by the
+// erasure job (worker.ErasureJob) shortly after.
+func reviewErasureRequest(c *gin.Context, newStatus models.ErasureRequestStatus) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid erasure request id"})
+		return
+	}
+
+	var req reviewErasureRequestBody
+	if err := c.ShouldBindJSON(&req); err != nil {
+		// body is optional
+		req = reviewErasureRequestBody{}
+	}
+
+	var er models.ErasureRequest
+	var soleOwned []uint
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("User").First(&er, id).Error; err != nil {
+			return err
+		}
+		if er.Status != models.ErasureRequestPending {
+			return errErasureNotPending
+		}
+
+		// the job can't erase someone a shelter still depends on
+		if newStatus == models.ErasureRequestApproved {
+			var err error
+			if soleOwned, err = repository.SoleOwnedShelterIDs(tx, er.UserID); err != nil {
+				return err
+			}
+			if len(soleOwned) > 0 {
+				return repository.ErrSoleShelterOwner
+			}
+		}
+
+		now := time.Now()
+		er.Status = newStatus
+		er.ReviewNote = req.ReviewNote
+		er.ReviewedByID = &adminID
+		er.ReviewedAt = &now
+
+		return tx.Omit("User").Save(&er).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "erasure request not found"})
+		case errors.Is(err, errErasureNotPending):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrSoleShelterOwner):
+			c.JSON(http.StatusConflict, gin.H{"error": "user is the only owner of a shelter", "shelter_ids": soleOwned})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to review erasure request"})
+		}
+		return
+	}
+
+	// last message to the address before it's gone
+	if newStatus == models.ErasureRequestApproved {
+		sendNotification(worker.Notification{
+			To:      er.User.Email,
+			Subject: "Your data will be erased",
+			Body: "Your request to erase your personal data was approved. " +
+				"Your account will be closed and your data anonymized within the next few minutes.",
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"erasure_request": er})
+}
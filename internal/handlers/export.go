@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportSection is one part of the personal data bundle; in ZIP exports
+// every section becomes its own <name>.json file.
+type exportSection struct {
+	Name string
+	Data interface{}
+}
+
+// personalDataSections collects everything stored about the user.
+func personalDataSections(userID uint) ([]exportSection, error) {
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	var (
+		adoptions    []models.AdoptionRequest
+		applications []models.ShelterApplication
+		memberships  []models.ShelterMember
+		shelters     []models.Shelter
+		sessions     []models.Session
+		identities   []models.UserIdentity
+		apiKeys      []models.APIKey
+		erasures     []models.ErasureRequest
+		messages     []models.AdoptionMessage
+	)
+	queries := []struct {
+		dest  interface{}
+		query string
+	}{
+		{&adoptions, "user_id = ?"},
+		{&applications, "user_id = ?"},
+		{&memberships, "user_id = ?"},
+		{&shelters, "owner_user_id = ?"},
+		{&sessions, "user_id = ?"},
+		{&identities, "user_id = ?"},
+		{&apiKeys, "created_by_id = ?"},
+		{&erasures, "user_id = ?"},
+		{&messages, "sender_id = ?"},
+	}
+	for _, q := range queries {
+		if err := database.DB.Where(q.query, userID).Order("created_at").Find(q.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return []exportSection{
+		{"profile", user},
+		{"adoption_requests", adoptions},
+		{"shelter_applications", applications},
+		{"shelter_memberships", memberships},
+		{"owned_shelters", shelters},
+		{"sessions", sessions},
+		{"linked_identities", identities},
+		{"api_keys", apiKeys},
+		{"erasure_requests", erasures},
+		{"adoption_messages", messages},
+	}, nil
+}
+
+// GET /me/export?format=json|zip
+func ExportMyData(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or zip"})
+		return
+	}
+
+	sections, err := personalDataSections(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export data"})
+		return
+	}
+
+	exportedAt := time.Now().UTC()
+	filename := fmt.Sprintf("personal-data-%d-%s", userID, exportedAt.Format("20060102"))
+
+	if format == "json" {
+		bundle := gin.H{"exported_at": exportedAt}
+		for _, s := range sections {
+			bundle[s.Name] = s.Data
+		}
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		c.JSON(http.StatusOK, bundle)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+
+	zw := zip.NewWriter(c.Writer)
+	for _, s := range sections {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: s.Name + ".json", Method: zip.Deflate, Modified: exportedAt})
+		if err != nil {
+			return
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(s.Data); err != nil {
+			return
+		}
+	}
+	zw.Close()
+}
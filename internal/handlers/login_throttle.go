@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type loginThrottlePolicy struct {
+	// failures allowed before backoff starts
+	FreeAttempts int
+	// first backoff delay, doubled for every further failure up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// failures that lock the key entirely (0 = never)
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	// failures older than this are forgotten
+	Window time.Duration
+}
+
+var (
+	accountLoginPolicy = loginThrottlePolicy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        15 * time.Minute,
+		LockoutAfter:    10,
+		LockoutDuration: 30 * time.Minute,
+		Window:          24 * time.Hour,
+	}
+	// an IP may legitimately serve many users (NAT), so it gets more slack
+	// and is only ever slowed down, never locked
+	ipLoginPolicy = loginThrottlePolicy{
+		FreeAttempts: 20,
+		BaseDelay:    time.Second,
+		MaxDelay:     15 * time.Minute,
+		Window:       time.Hour,
+	}
+)
+
+func accountThrottleKey(email string) string {
+	return models.AccountThrottleKey(email)
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// checkLoginThrottle rejects the attempt if any of the keys is blocked or
+// locked, writing a 429/423 response with Retry-After. Keys are checked in
+// order, so put the IP first.
+func checkLoginThrottle(c *gin.Context, keys ...string) bool {
+	now := time.Now()
+
+	for _, key := range keys {
+		var t models.LoginThrottle
+		if err := database.DB.Where("key = ?", key).First(&t).Error; err != nil {
+			continue
+		}
+
+		if t.LockedUntil != nil && now.Before(*t.LockedUntil) {
+			setRetryAfter(c, t.LockedUntil.Sub(now))
+			c.JSON(http.StatusLocked, gin.H{"error": "account temporarily locked after too many failed logins"})
+			return false
+		}
+
+		if t.BlockedUntil != nil && now.Before(*t.BlockedUntil) {
+			setRetryAfter(c, t.BlockedUntil.Sub(now))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed login attempts, try again later"})
+			return false
+		}
+	}
+
+	return true
+}
+
+// recordLoginFailure bumps the failure counter for a key and applies the
+// policy's backoff or lockout.
+func recordLoginFailure(key string, policy loginThrottlePolicy) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var t models.LoginThrottle
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&t).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err != nil {
+			t = models.LoginThrottle{Key: key}
+		}
+
+		// start over once the window has passed or a lockout has been served
+		if now.Sub(t.LastFailureAt) > policy.Window || (t.LockedUntil != nil && now.After(*t.LockedUntil)) {
+			t.Failures = 0
+			t.LockedUntil = nil
+		}
+
+		t.Failures++
+		t.LastFailureAt = now
+		t.BlockedUntil = nil
+
+		switch {
+		case policy.LockoutAfter > 0 && t.Failures >= policy.LockoutAfter:
+			until := now.Add(policy.LockoutDuration)
+			t.LockedUntil = &until
+		case t.Failures >= policy.FreeAttempts:
+			until := now.Add(policy.backoff(t.Failures))
+			t.BlockedUntil = &until
+		}
+
+		return tx.Save(&t).Error
+	})
+}
+
+// backoff is BaseDelay doubled for every failure past the free attempts.
+func (p loginThrottlePolicy) backoff(failures int) time.Duration {
+	exp := failures - p.FreeAttempts
+	if exp > 30 {
+		return p.MaxDelay
+	}
+	d := time.Duration(float64(p.BaseDelay) * math.Pow(2, float64(exp)))
+	if d > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return d
+}
+
+// recordFailedLogin applies a failure to both the account and the IP.
+func recordFailedLogin(accountKey, ipKey string) {
+	if err := recordLoginFailure(accountKey, accountLoginPolicy); err != nil {
+		log.Printf("failed to record login failure for %s: %v", accountKey, err)
+	}
+	if err := recordLoginFailure(ipKey, ipLoginPolicy); err != nil {
+		log.Printf("failed to record login failure for %s: %v", ipKey, err)
+	}
+}
+
+func clearLoginThrottle(key string) error {
+	return database.DB.Where("key = ?", key).Delete(&models.LoginThrottle{}).Error
+}
+
+func setRetryAfter(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+}
+
+// POST /admin/users/:id/unlock
+func UnlockUser(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if err := clearLoginThrottle(accountThrottleKey(user.Email)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlock user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user unlocked"})
+}
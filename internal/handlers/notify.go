@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"pet-adoption-api/internal/worker"
+)
+
+// This is set in main.go: handlers.Notifications = aw.Notifications
+var Notifications chan worker.Notification
+
+// sendNotification hands a notification to the worker (non-blocking).
+func sendNotification(n worker.Notification) {
+	if Notifications == nil {
+		return
+	}
+
+	select {
+	case Notifications <- n:
+	default:
+		// channel full → drop, but leave a trace
+		log.Printf("notification queue full, dropped %q for %s", n.Subject, n.To)
+	}
+}
+
+// appURL builds a link into the front-end, e.g. for emailed tokens.
+func appURL(path string) string {
+	base := os.Getenv("APP_BASE_URL")
+	if base == "" {
+		base = "http://localhost:8081"
+	}
+	return strings.TrimRight(base, "/") + path
+}
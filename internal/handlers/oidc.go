@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"pet-adoption-api/internal/auth"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const oidcLoginStateDuration = 10 * time.Minute
+
+var (
+	errOIDCStateInvalid    = errors.New("invalid or expired login state")
+	errOIDCEmailUnverified = errors.New("the identity provider has not verified this email address")
+)
+
+// oidcProvider is nil when OIDC login is not configured.
+var oidcProvider *auth.OIDCProvider
+
+// InitOIDC should be called from main when config.OIDC is set.
+func InitOIDC(p *auth.OIDCProvider) {
+	oidcProvider = p
+}
+
+// GET /auth/oidc/login
+// Redirects to the identity provider with a fresh state, nonce and PKCE challenge.
+func OIDCLogin(c *gin.Context) {
+	if oidcProvider == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OIDC login is not configured"})
+		return
+	}
+
+	state, stateHash, err := auth.NewOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+		return
+	}
+	nonce, err := auth.NewID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+		return
+	}
+	verifier, err := auth.NewPKCEVerifier()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+		return
+	}
+
+	redirect, err := oidcProvider.AuthCodeURL(c.Request.Context(), state, nonce, verifier)
+	if err != nil {
+		log.Printf("OIDC login: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "identity provider unavailable"})
+		return
+	}
+
+	// abandoned logins are cleaned up as new ones start
+	database.DB.Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginState{})
+
+	loginState := models.OIDCLoginState{
+		StateHash:    stateHash,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcLoginStateDuration),
+	}
+	if err := database.DB.Create(&loginState).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirect)
+}
+
+// GET /auth/oidc/callback?code=...&state=...
+// Redeems the code, then logs in the linked user (creating or linking the
+// account by verified email on first use).
+func OIDCCallback(c *gin.Context) {
+	if oidcProvider == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OIDC login is not configured"})
+		return
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login failed at the identity provider: " + providerErr})
+		return
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code and state are required"})
+		return
+	}
+
+	loginState, err := consumeOIDCLoginState(state)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := oidcProvider.Exchange(c.Request.Context(), code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.Printf("OIDC callback: %v", err)
+		if errors.Is(err, auth.ErrInvalidIDToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid ID token"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "could not complete login with the identity provider"})
+		return
+	}
+
+	user, err := userForOIDCIdentity(claims)
+	if err != nil {
+		if errors.Is(err, errOIDCEmailUnverified) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sign in"})
+		return
+	}
+
+	completeLogin(c, user)
+}
+
+// consumeOIDCLoginState looks up and deletes the pending login for a state,
+// so a callback can't be replayed.
+func consumeOIDCLoginState(state string) (models.OIDCLoginState, error) {
+	var loginState models.OIDCLoginState
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state_hash = ?", auth.HashToken(state)).First(&loginState).Error; err != nil {
+			return errOIDCStateInvalid
+		}
+
+		// delete-and-check so two concurrent callbacks can't both use it
+		res := tx.Where("state_hash = ?", loginState.StateHash).Delete(&models.OIDCLoginState{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 || time.Now().After(loginState.ExpiresAt) {
+			return errOIDCStateInvalid
+		}
+		return nil
+	})
+	return loginState, err
+}
+
+// userForOIDCIdentity finds the user linked to the provider account. On the
+// first login the account is linked to the user with the same (verified)
+// email, or a new user is created.
+func userForOIDCIdentity(claims *auth.IDTokenClaims) (models.User, error) {
+	var user models.User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var identity models.UserIdentity
+		err := tx.Where("issuer = ? AND subject = ?", claims.Issuer, claims.Subject).First(&identity).Error
+		if err == nil {
+			return tx.First(&user, identity.UserID).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if claims.Email == "" || !claims.EmailVerified {
+			return errOIDCEmailUnverified
+		}
+
+		now := time.Now()
+		err = tx.Where("LOWER(email) = ?", strings.ToLower(claims.Email)).First(&user).Error
+		switch {
+		case err == nil:
+			// the provider vouches for the address, so it counts as verified here too
+			if user.EmailVerifiedAt == nil {
+				user.EmailVerifiedAt = &now
+				if err := tx.Model(&user).Update("email_verified_at", now).Error; err != nil {
+					return err
+				}
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			user, err = newOIDCUser(claims, now)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		return tx.Create(&models.UserIdentity{
+			UserID:  user.ID,
+			Issuer:  claims.Issuer,
+			Subject: claims.Subject,
+			Email:   claims.Email,
+		}).Error
+	})
+	return user, err
+}
+
+// newOIDCUser builds a plain user for a first-time OIDC login. The password
+// is random and never shown; the user can set one through password reset.
+func newOIDCUser(claims *auth.IDTokenClaims, verifiedAt time.Time) (models.User, error) {
+	password, _, err := auth.NewOpaqueToken()
+	if err != nil {
+		return models.User{}, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.Email
+	}
+
+	return models.User{
+		Name:            name,
+		Email:           claims.Email,
+		PasswordHash:    string(hash),
+		Role:            models.RoleUser,
+		EmailVerifiedAt: &verifiedAt,
+	}, nil
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"pet-adoption-api/internal/auth"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	mockOIDCClientID    = "pet-adoption"
+	mockOIDCRedirectURI = "http://api.test/auth/oidc/callback"
+)
+
+// mockOIDCProvider is a minimal identity provider: discovery, JWKS, an
+// authorize endpoint that signs in the configured account right away, and a
+// token endpoint that checks the PKCE verifier.
+type mockOIDCProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu            sync.Mutex
+	subject       string
+	email         string
+	emailVerified bool
+	codes         map[string]url.Values // code -> authorize request
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	m := &mockOIDCProvider{key: key, codes: map[string]url.Values{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		signer, _ := auth.NewPrivateKey("mock", m.key)
+		keys, _ := auth.NewKeyring(signer)
+		json.NewEncoder(w).Encode(keys.JWKS())
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		code, _, _ := auth.NewOpaqueToken()
+		m.mu.Lock()
+		m.codes[code] = q
+		m.mu.Unlock()
+		http.Redirect(w, r, q.Get("redirect_uri")+"?code="+code+"&state="+url.QueryEscape(q.Get("state")), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		m.mu.Lock()
+		authz, ok := m.codes[r.PostForm.Get("code")]
+		delete(m.codes, r.PostForm.Get("code"))
+		subject, email, verified := m.subject, m.email, m.emailVerified
+		m.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || r.PostForm.Get("redirect_uri") != authz.Get("redirect_uri") ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != authz.Get("code_challenge") {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            m.server.URL,
+			"aud":            mockOIDCClientID,
+			"sub":            subject,
+			"email":          email,
+			"email_verified": verified,
+			"name":           "Idp Volunteer",
+			"nonce":          authz.Get("nonce"),
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		})
+		token.Header["kid"] = "mock"
+		idToken, _ := token.SignedString(m.key)
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "unused", "id_token": idToken})
+	})
+	m.server = httptest.NewServer(mux)
+	return m
+}
+
+func (m *mockOIDCProvider) signInAs(subject, email string, verified bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subject, m.email, m.emailVerified = subject, email, verified
+}
+
+// oidcLogin runs the browser side of the flow and returns our callback's
+// response plus the callback URL (to try replaying it).
+func oidcLogin(t *testing.T, m *mockOIDCProvider) (*httptest.ResponseRecorder, string) {
+	w := doJSON("GET", "/auth/oidc/login", "", nil)
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	noFollow := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noFollow.Get(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	assert.NoError(t, err)
+	return doJSON("GET", callback.RequestURI(), "", nil), callback.RequestURI()
+}
+
+func TestOIDCLogin_CreatesAndLinksUsers(t *testing.T) {
+	m := newMockOIDCProvider(t)
+	defer m.server.Close()
+
+	InitOIDC(auth.NewOIDCProvider(auth.OIDCConfig{
+		Issuer:      m.server.URL,
+		ClientID:    mockOIDCClientID,
+		RedirectURI: mockOIDCRedirectURI,
+	}, m.server.Client()))
+	defer InitOIDC(nil)
+
+	// first login creates a verified user
+	m.signInAs("idp-volunteer", "volunteer@idp.test", true)
+	w, callback := oidcLogin(t, m)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NotEmpty(t, resp["token"])
+
+	var created models.User
+	assert.NoError(t, database.DB.Where("email = ?", "volunteer@idp.test").First(&created).Error)
+	assert.Equal(t, models.RoleUser, created.Role)
+	assert.NotNil(t, created.EmailVerifiedAt)
+
+	// the state is single use
+	w = doJSON("GET", callback, "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// signing in again finds the same user through the linked identity
+	w, _ = oidcLogin(t, m)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var count int64
+	database.DB.Model(&models.UserIdentity{}).Where("user_id = ?", created.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// an existing password account is linked by its email
+	existing := models.User{Name: "Existing", Email: "existing-sso@test.com", PasswordHash: "x", Role: models.RoleUser}
+	database.DB.Create(&existing)
+	m.signInAs("idp-existing", "Existing-SSO@test.com", true)
+	w, _ = oidcLogin(t, m)
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, float64(existing.ID), resp["user"].(map[string]interface{})["id"])
+	database.DB.First(&existing, existing.ID)
+	assert.NotNil(t, existing.EmailVerifiedAt)
+
+	// unverified provider emails are never linked or used for new accounts
+	m.signInAs("idp-unverified", "existing-sso@test.com", false)
+	w, _ = oidcLogin(t, m)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
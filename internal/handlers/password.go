@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"pet-adoption-api/internal/worker"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const passwordResetTokenDuration = time.Hour
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// POST /auth/password/forgot
+func ForgotPassword(c *gin.Context) {
+	var req forgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	// same answer whether or not the account exists, so this can't be used
+	// to discover registered emails
+	resp := gin.H{"message": "if an account exists for this email, a reset link has been sent"}
+
+	var user models.User
+	if err := database.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		c.JSON(http.StatusAccepted, resp)
+		return
+	}
+
+	token, err := issueUserToken(database.DB, user.ID, models.TokenPurposePasswordReset, passwordResetTokenDuration)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create reset token"})
+		return
+	}
+
+	sendNotification(worker.Notification{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: "Someone asked to reset the password for your account. " +
+			"If it was you, open the link below within the next hour:\n\n" +
+			appURL("/reset-password?token="+url.QueryEscape(token)) +
+			"\n\nIf you didn't ask for this, you can ignore this email.",
+	})
+
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// POST /auth/password/reset
+func ResetPassword(c *gin.Context) {
+	var req resetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		ut, err := consumeUserToken(tx, req.Token, models.TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", ut.UserID).
+			Update("password_hash", string(hash)).Error; err != nil {
+			return err
+		}
+
+		// whoever had the old password may also hold tokens
+		return revokeUserSessions(tx, ut.UserID)
+	})
+	if err != nil {
+		if errors.Is(err, errUserTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password has been reset, please log in again"})
+}
@@ -77,6 +77,11 @@ func CreatePet(c *gin.Context) {
 		return
 	}
 
+	// only staff of that shelter (or admin) may list pets in it
+	if !authorizeShelter(c, shelter.ID, models.PermManagePets) {
+		return
+	}
+
 	pet := models.Pet{
 		ShelterID:   req.ShelterID,
 		Name:        req.Name,
@@ -121,19 +126,48 @@ func UpdatePet(c *gin.Context) {
 		return
 	}
 
+	if !authorizeShelter(c, pet.ShelterID, models.PermManagePets) {
+		return
+	}
+
 	var req updatePetRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
 		return
 	}
 
+	// reservations go through holds (POST /pets/:id/hold) so they can lapse,
+	// adoptions through approval; an omitted status leaves it unchanged
+	status := pet.Status
+	if req.Status != "" {
+		status = models.PetStatus(req.Status)
+	}
+	if status != pet.Status {
+		switch status {
+		case models.PetStatusAvailable:
+		case models.PetStatusReserved:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "use POST /pets/:id/hold to reserve a pet"})
+			return
+		case models.PetStatusAdopted:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "pets are marked adopted by approving an adoption request"})
+			return
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+			return
+		}
+		if pet.Status == models.PetStatusReserved {
+			c.JSON(http.StatusConflict, gin.H{"error": "pet is on hold; release the hold first"})
+			return
+		}
+	}
+
 	// Update fields from request
 	pet.Name = req.Name
 	pet.Species = req.Species
 	pet.Breed = req.Breed
 	pet.Age = req.Age
 	pet.Description = req.Description
-	pet.Status = models.PetStatus(req.Status)
+	pet.Status = status
 	pet.UpdatedAt = time.Now()
 
 	if err := database.DB.Save(&pet).Error; err != nil {
@@ -154,7 +188,17 @@ func DeletePet(c *gin.Context) {
 		return
 	}
 
-	if err := database.DB.Delete(&models.Pet{}, id).Error; err != nil {
+	var pet models.Pet
+	if err := database.DB.First(&pet, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pet not found"})
+		return
+	}
+
+	if !authorizeShelter(c, pet.ShelterID, models.PermManagePets) {
+		return
+	}
+
+	if err := database.DB.Delete(&pet).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete pet"})
 		return
 	}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"pet-adoption-api/internal/repository"
+	"pet-adoption-api/internal/worker"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultHoldDuration = 72 * time.Hour
+	maxHoldDuration     = 14 * 24 * time.Hour
+)
+
+var (
+	errHoldPetNotAvailable = errors.New("only available pets can be put on hold")
+	errHoldRequestMismatch = errors.New("adoption request is not for this pet")
+	errHoldRequestDecided  = errors.New("adoption request is no longer pending")
+	errNoActiveHold        = errors.New("pet is not on hold")
+)
+
+type placeHoldRequest struct {
+	AdoptionRequestID uint   `json:"adoption_request_id" binding:"required"`
+	Hours             int    `json:"hours"`
+	Note              string `json:"note"`
+}
+
+// POST /pets/:id/hold
+// Reserves the pet for one pending applicant, e.g. until a home visit has
+// taken place. The hold lapses on its own after the given hours.
+func PlacePetHold(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	petID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pet id"})
+		return
+	}
+
+	var req placeHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "adoption_request_id is required"})
+		return
+	}
+	// range-check before converting, a huge value would wrap around
+	duration := defaultHoldDuration
+	if req.Hours != 0 {
+		if req.Hours < 1 || req.Hours > int(maxHoldDuration/time.Hour) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "hours must be between 1 and 336"})
+			return
+		}
+		duration = time.Duration(req.Hours) * time.Hour
+	}
+
+	var pet models.Pet
+	if err := database.DB.First(&pet, petID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pet not found"})
+		return
+	}
+	if !authorizeShelter(c, pet.ShelterID, models.PermReviewAdoptions) {
+		return
+	}
+
+	var ar models.AdoptionRequest
+	var hold models.PetHold
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pet, petID).Error; err != nil {
+			return err
+		}
+		if pet.Status != models.PetStatusAvailable {
+			return errHoldPetNotAvailable
+		}
+
+		if err := tx.Preload("User").First(&ar, req.AdoptionRequestID).Error; err != nil {
+			return err
+		}
+		if ar.PetID != pet.ID {
+			return errHoldRequestMismatch
+		}
+		if ar.Status != models.AdoptionStatusPending {
+			return errHoldRequestDecided
+		}
+
+		hold = models.PetHold{
+			PetID:             pet.ID,
+			AdoptionRequestID: ar.ID,
+			PlacedByID:        userID,
+			Note:              req.Note,
+			ExpiresAt:         time.Now().Add(duration),
+		}
+		if err := tx.Create(&hold).Error; err != nil {
+			return err
+		}
+
+		pet.Status = models.PetStatusReserved
+		pet.UpdatedAt = time.Now()
+		return tx.Save(&pet).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "adoption request not found"})
+		case errors.Is(err, errHoldRequestMismatch):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, errHoldPetNotAvailable), errors.Is(err, errHoldRequestDecided):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to place hold"})
+		}
+		return
+	}
+
+	publishAdoptionEvent(ar, "Pet reserved for the applicant")
+	sendNotification(worker.Notification{
+		To:      ar.User.Email,
+		Subject: pet.Name + " is reserved for you",
+		Body: "Good news: the shelter has reserved " + pet.Name + " for you until " +
+			hold.ExpiresAt.Format("2 Jan 2006 15:04 MST") + " while they review your adoption request.",
+	})
+
+	c.JSON(http.StatusCreated, gin.H{"hold": hold, "pet": pet})
+}
+
+// DELETE /pets/:id/hold
+// Ends the hold early and puts the pet back on offer.
+func ReleasePetHold(c *gin.Context) {
+	petID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pet id"})
+		return
+	}
+
+	var pet models.Pet
+	if err := database.DB.First(&pet, petID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pet not found"})
+		return
+	}
+	if !authorizeShelter(c, pet.ShelterID, models.PermReviewAdoptions) {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		hold, err := repository.ActiveHold(tx, pet.ID)
+		if err != nil {
+			return err
+		}
+		if hold == nil {
+			return errNoActiveHold
+		}
+		released, err := repository.ReleaseHold(tx, hold.AdoptionRequestID)
+		if err != nil {
+			return err
+		}
+		if !released {
+			return errNoActiveHold
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errNoActiveHold) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to release hold"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
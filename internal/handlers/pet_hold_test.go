@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"pet-adoption-api/internal/worker"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// A hold reserves the pet for one applicant until it is released or lapses
+func TestPetHold_ReserveAndLapse(t *testing.T) {
+	pet := models.Pet{Name: "Held", Species: "Dog", ShelterID: 1, Status: models.PetStatusAvailable}
+	database.DB.Create(&pet)
+	petPath := "/pets/" + strconv.Itoa(int(pet.ID))
+
+	var requests []models.AdoptionRequest
+	for _, email := range []string{"held-for@test.com", "held-other@test.com"} {
+		user, _ := createProfileUser(t, email)
+		ar := models.AdoptionRequest{UserID: user.ID, PetID: pet.ID, Status: models.AdoptionStatusPending}
+		database.DB.Create(&ar)
+		requests = append(requests, ar)
+	}
+	late, lateSession := createProfileUser(t, "held-late@test.com")
+	now := time.Now()
+	database.DB.Model(&late).Update("email_verified_at", &now)
+
+	for _, hours := range []int{1000, -1, 1 << 62} {
+		w := doJSON("POST", petPath+"/hold", adminToken, gin.H{"adoption_request_id": requests[0].ID, "hours": hours})
+		assert.Equal(t, http.StatusBadRequest, w.Code, hours)
+	}
+
+	drainNotifications()
+	w := doJSON("POST", petPath+"/hold", adminToken, gin.H{"adoption_request_id": requests[0].ID, "hours": 48, "note": "home visit on Friday"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if sent := drainNotifications(); assert.Len(t, sent, 1) {
+		assert.Equal(t, "held-for@test.com", sent[0].To)
+	}
+
+	w = doJSON("POST", petPath+"/hold", adminToken, gin.H{"adoption_request_id": requests[1].ID})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// hidden from the available list, closed to new applications, status locked
+	w = doJSON("GET", "/pets/?status=available", "", nil)
+	var list struct {
+		Pets []models.Pet `json:"pets"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	for _, p := range list.Pets {
+		assert.NotEqual(t, pet.ID, p.ID)
+	}
+	w = doJSON("POST", "/adoptions/"+strconv.Itoa(int(pet.ID))+"/apply", lateSession.AccessToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON("PUT", petPath, adminToken, gin.H{"name": "Held", "species": "Dog", "status": "available"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doJSON("PATCH", "/adoptions/"+strconv.Itoa(int(requests[1].ID))+"/approve", adminToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// once it has expired the job lets it lapse, exactly once
+	database.DB.Model(&models.PetHold{}).Where("pet_id = ?", pet.ID).Update("expires_at", time.Now().Add(-time.Minute))
+	events := make(chan worker.AdoptionEvent, 10)
+	job := worker.NewPetHoldJob(database.DB, events)
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, job.RunOnce(context.Background()))
+	}
+	assert.Len(t, events, 1)
+	database.DB.First(&pet, pet.ID)
+	assert.Equal(t, models.PetStatusAvailable, pet.Status)
+
+	// staff can also end a hold early
+	w = doJSON("POST", petPath+"/hold", adminToken, gin.H{"adoption_request_id": requests[1].ID})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w = doJSON("DELETE", petPath+"/hold", adminToken, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = doJSON("DELETE", petPath+"/hold", adminToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	database.DB.First(&pet, pet.ID)
+	assert.Equal(t, models.PetStatusAvailable, pet.Status)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// Note: No setup function here. It uses the TestMain from shelter_test.go
+
+// Shelter owners manage pets of their own shelters only
+func TestPetWrites_ScopedToOwnShelter(t *testing.T) {
+	ownerSession, _ := startSession(shelterOwnerUser, false)
+
+	var ownShelter models.Shelter
+	database.DB.Where("owner_user_id = ?", shelterOwnerUser.ID).First(&ownShelter)
+
+	var admin models.User
+	database.DB.Where("email = ?", "admin@test.com").First(&admin)
+	otherShelter := models.Shelter{Name: "Someone Else's", OwnerUserID: admin.ID}
+	database.DB.Create(&otherShelter)
+
+	w := doJSON("POST", "/pets/", ownerSession.AccessToken, gin.H{"shelter_id": ownShelter.ID, "name": "Rex", "species": "Dog"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = doJSON("POST", "/pets/", ownerSession.AccessToken, gin.H{"shelter_id": otherShelter.ID, "name": "Rex", "species": "Dog"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	foreignPet := models.Pet{Name: "Not Yours", Species: "Cat", ShelterID: otherShelter.ID, Status: models.PetStatusAvailable}
+	database.DB.Create(&foreignPet)
+	foreignPath := "/pets/" + strconv.Itoa(int(foreignPet.ID))
+
+	w = doJSON("PUT", foreignPath, ownerSession.AccessToken, gin.H{"name": "Stolen", "species": "Cat", "status": "available"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON("DELETE", foreignPath, ownerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// admins still manage every shelter
+	w = doJSON("DELETE", foreignPath, adminToken, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+// A shelter user creating a shelter becomes its owner
+func TestCreateShelter_ShelterUserOwnsIt(t *testing.T) {
+	ownerSession, _ := startSession(shelterOwnerUser, false)
+
+	w := doJSON("POST", "/shelters/", ownerSession.AccessToken, gin.H{"name": "Second Branch"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var shelter models.Shelter
+	database.DB.Where("name = ?", "Second Branch").First(&shelter)
+	assert.Equal(t, shelterOwnerUser.ID, shelter.OwnerUserID)
+	assert.True(t, hasShelterPermission(shelterOwnerUser.ID, models.RoleShelter, shelter.ID, models.PermManagePets))
+
+	// but can't create one on someone else's behalf
+	w = doJSON("POST", "/shelters/", ownerSession.AccessToken, gin.H{"name": "Gift", "owner_user_id": shelterOwnerUser.ID + 1000})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// PUT /pets/:id may only make a pet available; adoption goes through approval
+func TestUpdatePet_Status(t *testing.T) {
+	pet := models.Pet{Name: "Status", Species: "Dog", ShelterID: 1, Status: models.PetStatusAvailable}
+	database.DB.Create(&pet)
+	petPath := "/pets/" + strconv.Itoa(int(pet.ID))
+
+	for _, status := range []string{"adopted", "reserved", "sold"} {
+		w := doJSON("PUT", petPath, adminToken, gin.H{"name": "Status", "species": "Dog", "status": status})
+		assert.Equal(t, http.StatusBadRequest, w.Code, status)
+	}
+
+	// an omitted status leaves it as it was
+	database.DB.Model(&pet).Update("status", models.PetStatusAdopted)
+	w := doJSON("PUT", petPath, adminToken, gin.H{"name": "Renamed", "species": "Dog"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	database.DB.First(&pet, pet.ID)
+	assert.Equal(t, models.PetStatusAdopted, pet.Status)
+	assert.Equal(t, "Renamed", pet.Name)
+
+	w = doJSON("PUT", petPath, adminToken, gin.H{"name": "Renamed", "species": "Dog", "status": "available"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	database.DB.First(&pet, pet.ID)
+	assert.Equal(t, models.PetStatusAvailable, pet.Status)
+}
@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"pet-adoption-api/internal/repository"
+	"pet-adoption-api/internal/worker"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const emailChangeTokenDuration = 24 * time.Hour
+
+var (
+	errEmailTaken = errors.New("email is already in use")
+	errSoleOwner  = errors.New("you are the only owner of a shelter; add another owner first")
+)
+
+type updateProfileRequest struct {
+	Name    *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Email   *string `json:"email" binding:"omitempty,email"`
+	Phone   *string `json:"phone" binding:"omitempty,max=32"`
+	Address *string `json:"address" binding:"omitempty,max=500"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type deleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// GET /me
+func GetMe(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// PATCH /me
+// A new email only takes effect once confirmed from the new inbox; until
+// then it is kept as pending_email.
+func UpdateMe(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req updateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Phone != nil {
+		updates["phone"] = strings.TrimSpace(*req.Phone)
+	}
+	if req.Address != nil {
+		updates["address"] = strings.TrimSpace(*req.Address)
+	}
+
+	var newEmail, emailToken string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if req.Email != nil {
+			email := strings.TrimSpace(*req.Email)
+			if strings.EqualFold(email, user.Email) {
+				// changing back to the current address drops a pending change
+				updates["pending_email"] = nil
+			} else {
+				var taken int64
+				tx.Model(&models.User{}).Where("LOWER(email) = ?", strings.ToLower(email)).Count(&taken)
+				if taken > 0 {
+					return errEmailTaken
+				}
+
+				token, err := issueUserToken(tx, user.ID, models.TokenPurposeEmailChange, emailChangeTokenDuration)
+				if err != nil {
+					return err
+				}
+				updates["pending_email"] = email
+				newEmail, emailToken = email, token
+			}
+		}
+
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&user).Updates(updates).Error
+	})
+	if err != nil {
+		if errors.Is(err, errEmailTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update profile"})
+		return
+	}
+
+	if emailToken != "" {
+		sendNotification(worker.Notification{
+			To:      newEmail,
+			Subject: "Confirm your new email address",
+			Body: "Please confirm this address for your account by opening the link below " +
+				"within the next 24 hours:\n\n" +
+				appURL("/confirm-email?token="+url.QueryEscape(emailToken)),
+		})
+		sendNotification(worker.Notification{
+			To:      user.Email,
+			Subject: "Your email address is being changed",
+			Body: "Someone asked to change the email address of your account to " + newEmail +
+				". The change only happens once the new address is confirmed. " +
+				"If this wasn't you, change your password.",
+		})
+	}
+
+	database.DB.First(&user, userID)
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+type confirmEmailChangeRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// POST /auth/confirm-email
+// Switches the account to its pending email address.
+func ConfirmEmailChange(c *gin.Context) {
+	var req confirmEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		ut, err := consumeUserToken(tx, req.Token, models.TokenPurposeEmailChange)
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		if err := tx.First(&user, ut.UserID).Error; err != nil || user.PendingEmail == nil {
+			return errUserTokenInvalid
+		}
+
+		// someone may have registered the address in the meantime
+		var taken int64
+		tx.Model(&models.User{}).
+			Where("LOWER(email) = ? AND id <> ?", strings.ToLower(*user.PendingEmail), user.ID).
+			Count(&taken)
+		if taken > 0 {
+			return errEmailTaken
+		}
+
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"email":             *user.PendingEmail,
+			"pending_email":     nil,
+			"email_verified_at": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errUserTokenInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, errEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change email"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email address changed"})
+}
+
+// checkCurrentPassword verifies a password the signed-in user re-enters.
+// Failures count against the login throttle, so a stolen access token
+// can't be used to guess the password. On failure it has already written
+// the response.
+func checkCurrentPassword(c *gin.Context, user models.User, password, wrongMsg string) bool {
+	accountKey := accountThrottleKey(user.Email)
+	ipKey := ipThrottleKey(c.ClientIP())
+	if !checkLoginThrottle(c, ipKey, accountKey) {
+		return false
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		recordFailedLogin(accountKey, ipKey)
+		c.JSON(http.StatusForbidden, gin.H{"error": wrongMsg})
+		return false
+	}
+
+	clearLoginThrottle(accountKey)
+	return true
+}
+
+// POST /me/password
+// Other sessions are logged out; the current one stays signed in.
+func ChangePassword(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req changePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if !checkCurrentPassword(c, user, req.CurrentPassword, "current password is incorrect") {
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password_hash", string(hash)).Error; err != nil {
+			return err
+		}
+		return revokeOtherSessions(tx, user.ID, c.GetString("sessionID"))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change password"})
+		return
+	}
+
+	sendNotification(worker.Notification{
+		To:      user.Email,
+		Subject: "Your password was changed",
+		Body:    "The password for your account was just changed. If this wasn't you, reset it right away.",
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "password changed"})
+}
+
+// DELETE /me
+// Closes the account: pending adoption requests are cancelled, shelter
+// memberships end (sole owners must hand over first), and the user is
+// soft-deleted with their email and contact details cleared. Decided
+// adoption requests stay on record for the shelters.
+func DeleteMe(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req deleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if !checkCurrentPassword(c, user, req.Password, "password is incorrect") {
+		return
+	}
+
+	var cancelled []models.AdoptionRequest
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := repository.LeaveShelters(tx, user.ID); err != nil {
+			return err
+		}
+
+		var err error
+		if cancelled, err = repository.CancelPendingAdoptions(tx, user.ID); err != nil {
+			return err
+		}
+
+		return repository.CloseUserAccount(tx, user.ID)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrSoleShelterOwner) {
+			soleOwned, _ := repository.SoleOwnedShelterIDs(database.DB, user.ID)
+			c.JSON(http.StatusConflict, gin.H{"error": errSoleOwner.Error(), "shelter_ids": soleOwned})
+			return
+		}
+		log.Printf("failed to delete user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete account"})
+		return
+	}
+
+	for _, ar := range cancelled {
+		publishAdoptionEvent(ar, "Adoption request cancelled: applicant closed their account")
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"pet-adoption-api/internal/auth"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"pet-adoption-api/internal/worker"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func createProfileUser(t *testing.T, email string) (models.User, tokenPair) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	user := models.User{Name: "Profile", Email: email, PasswordHash: string(hash), Role: models.RoleUser}
+	assert.NoError(t, database.DB.Create(&user).Error)
+	pair, err := startSession(user, false)
+	assert.NoError(t, err)
+	return user, pair
+}
+
+// Profile edits apply at once, except the email which waits for confirmation
+func TestProfile_UpdateAndChangeEmail(t *testing.T) {
+	user, pair := createProfileUser(t, "profile@test.com")
+
+	drainNotifications()
+	w := doJSON("PATCH", "/me", pair.AccessToken, gin.H{
+		"phone":   "+49 30 1234567",
+		"address": "Main St 1",
+		"email":   "profile-new@test.com",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var stored models.User
+	database.DB.First(&stored, user.ID)
+	assert.Equal(t, "+49 30 1234567", stored.Phone)
+	assert.Equal(t, "profile@test.com", stored.Email)
+	if assert.NotNil(t, stored.PendingEmail) {
+		assert.Equal(t, "profile-new@test.com", *stored.PendingEmail)
+	}
+
+	// the link goes to the new address, the old one is warned
+	var token string
+	for _, n := range drainNotifications() {
+		if n.To == "profile-new@test.com" {
+			token = mailedTokenPattern.FindStringSubmatch(n.Body)[1]
+		}
+	}
+	assert.NotEmpty(t, token)
+
+	w = doJSON("PATCH", "/me", pair.AccessToken, gin.H{"email": "admin@test.com"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doJSON("POST", "/auth/confirm-email", "", gin.H{"token": token})
+	assert.Equal(t, http.StatusOK, w.Code)
+	database.DB.First(&stored, user.ID)
+	assert.Equal(t, "profile-new@test.com", stored.Email)
+	assert.Nil(t, stored.PendingEmail)
+	assert.NotNil(t, stored.EmailVerifiedAt)
+}
+
+// Changing the password needs the current one and logs out other sessions
+func TestProfile_ChangePassword(t *testing.T) {
+	defer database.DB.Where("1 = 1").Delete(&models.LoginThrottle{})
+
+	user, pair := createProfileUser(t, "password-change@test.com")
+	other, _ := startSession(user, false)
+
+	// wrong guesses are throttled like failed logins
+	for i := 0; i < accountLoginPolicy.FreeAttempts; i++ {
+		w := doJSON("POST", "/me/password", pair.AccessToken, gin.H{"current_password": "wrong", "new_password": "new-password"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	}
+	w := doJSON("POST", "/me/password", pair.AccessToken, gin.H{"current_password": "password123", "new_password": "new-password"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	w = doJSON("DELETE", "/me", pair.AccessToken, gin.H{"password": "password123"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	w = doJSON("POST", "/admin/users/"+strconv.Itoa(int(user.ID))+"/unlock", adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON("POST", "/me/password", pair.AccessToken, gin.H{"current_password": "password123", "new_password": "new-password"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, http.StatusOK, getMyAdoptions(pair.AccessToken))
+	assert.Equal(t, http.StatusUnauthorized, getMyAdoptions(other.AccessToken))
+	loginAs(t, user.Email, "new-password")
+}
+
+// Deleting an account cancels pending requests and is refused for sole shelter owners
+func TestProfile_DeleteAccount(t *testing.T) {
+	defer database.DB.Where("1 = 1").Delete(&models.LoginThrottle{})
+
+	user, pair := createProfileUser(t, "leaving@test.com")
+
+	shelter := models.Shelter{Name: "Leaving Shelter", OwnerUserID: user.ID}
+	database.DB.Create(&shelter)
+	pet := models.Pet{Name: "Left Behind", Species: "Dog", ShelterID: 1, Status: models.PetStatusAvailable}
+	database.DB.Create(&pet)
+	pending := models.AdoptionRequest{UserID: user.ID, PetID: pet.ID, Status: models.AdoptionStatusPending}
+	database.DB.Create(&pending)
+
+	w := doJSON("DELETE", "/me", pair.AccessToken, gin.H{"password": "password123"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// with a second owner the account can go, and the shelter stays with them
+	coOwner := models.User{Name: "Co Owner", Email: "co-owner@test.com", PasswordHash: "x", Role: models.RoleShelter}
+	database.DB.Create(&coOwner)
+	database.DB.Create(&models.ShelterMember{ShelterID: shelter.ID, UserID: coOwner.ID, Role: models.ShelterRoleOwner, Status: models.MembershipActive})
+
+	w = doJSON("DELETE", "/me", pair.AccessToken, gin.H{"password": "wrong"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON("DELETE", "/me", pair.AccessToken, gin.H{"password": "password123"})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	database.DB.First(&pending, pending.ID)
+	assert.Equal(t, models.AdoptionStatusCancelled, pending.Status)
+	database.DB.First(&shelter, shelter.ID)
+	assert.Equal(t, coOwner.ID, shelter.OwnerUserID)
+
+	assert.Error(t, database.DB.First(&models.User{}, user.ID).Error)
+	assert.Equal(t, http.StatusUnauthorized, getMyAdoptions(pair.AccessToken))
+
+	// the email can be registered again
+	w = doJSON("POST", "/auth/register", "", gin.H{"name": "Again", "email": "leaving@test.com", "password": "password123"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+// Users can export their data; approved erasure anonymizes them but keeps adoption history
+func TestPersonalData_ExportAndErasure(t *testing.T) {
+	user, pair := createProfileUser(t, "gdpr@test.com")
+	database.DB.Model(&user).Update("phone", "+1 555 0100")
+	pet := models.Pet{Name: "Gdpr Pet", Species: "Cat", ShelterID: 1, Status: models.PetStatusAvailable}
+	database.DB.Create(&pet)
+	ar := models.AdoptionRequest{UserID: user.ID, PetID: pet.ID, Status: models.AdoptionStatusPending, Message: "I live at 1 Private Rd"}
+	database.DB.Create(&ar)
+	key := models.APIKey{ShelterID: 1, Name: "gdpr", Prefix: "pak_gdprtest", KeyHash: auth.HashToken("pak_gdprtest"),
+		Scopes: []models.APIKeyScope{models.ScopeAdoptionsRead}, CreatedByID: user.ID}
+	database.DB.Create(&key)
+
+	w := doJSON("GET", "/me/export", pair.AccessToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var bundle struct {
+		Profile          models.User              `json:"profile"`
+		AdoptionRequests []models.AdoptionRequest `json:"adoption_requests"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &bundle)
+	assert.Equal(t, "+1 555 0100", bundle.Profile.Phone)
+	if assert.Len(t, bundle.AdoptionRequests, 1) {
+		assert.Equal(t, ar.Message, bundle.AdoptionRequests[0].Message)
+	}
+
+	w = doJSON("GET", "/me/export?format=zip", pair.AccessToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if assert.NoError(t, err) {
+		var names []string
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		assert.Contains(t, names, "profile.json")
+		assert.Contains(t, names, "adoption_requests.json")
+	}
+
+	w = doJSON("POST", "/me/erasure", pair.AccessToken, gin.H{"reason": "moving away"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var created struct {
+		ErasureRequest models.ErasureRequest `json:"erasure_request"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	w = doJSON("POST", "/me/erasure", pair.AccessToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// nothing happens before an admin approves
+	job := worker.NewErasureJob(database.DB, nil)
+	assert.NoError(t, job.RunOnce(context.Background()))
+	assert.Equal(t, http.StatusOK, getMyAdoptions(pair.AccessToken))
+	w = doWithAPIKey("GET", "/adoptions/shelter", "pak_gdprtest", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	approvePath := "/admin/erasure-requests/" + strconv.Itoa(int(created.ErasureRequest.ID)) + "/approve"
+	w = doJSON("PATCH", approvePath, adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, job.RunOnce(context.Background()))
+
+	var erased models.User
+	database.DB.Unscoped().First(&erased, user.ID)
+	assert.Equal(t, "Deleted user", erased.Name)
+	assert.NotEqual(t, "gdpr@test.com", erased.Email)
+	assert.Empty(t, erased.Phone)
+	assert.Equal(t, http.StatusUnauthorized, getMyAdoptions(pair.AccessToken))
+	w = doWithAPIKey("GET", "/adoptions/shelter", "pak_gdprtest", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// the request survives for statistics, without the free text
+	database.DB.First(&ar, ar.ID)
+	assert.Equal(t, pet.ID, ar.PetID)
+	assert.Equal(t, models.AdoptionStatusCancelled, ar.Status)
+	assert.Empty(t, ar.Message)
+
+	var er models.ErasureRequest
+	database.DB.First(&er, created.ErasureRequest.ID)
+	assert.Equal(t, models.ErasureRequestCompleted, er.Status)
+	assert.NotNil(t, er.CompletedAt)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type createQuestionnaireRequest struct {
+	Questions []models.Question `json:"questions" binding:"required"`
+}
+
+var errQuestionnaireConflict = errors.New("the questionnaire was changed at the same time; reload it and try again")
+
+// activeQuestionnaire returns the shelter's current questionnaire version,
+// or nil if the shelter doesn't use one. A version without questions means
+// the shelter turned its questionnaire off.
+func activeQuestionnaire(db *gorm.DB, shelterID uint) (*models.Questionnaire, error) {
+	qn, err := latestQuestionnaire(db, shelterID)
+	if err != nil || qn == nil || len(qn.Questions) == 0 {
+		return nil, err
+	}
+	return qn, nil
+}
+
+func latestQuestionnaire(db *gorm.DB, shelterID uint) (*models.Questionnaire, error) {
+	var qn models.Questionnaire
+	err := db.Where("shelter_id = ?", shelterID).Order("version DESC").First(&qn).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &qn, nil
+}
+
+// publishQuestionnaire saves questions as the shelter's next version. A
+// concurrent publish trips the (shelter_id, version) unique index instead
+// of creating the same version twice; that comes back as
+// errQuestionnaireConflict.
+func publishQuestionnaire(shelterID, userID uint, questions []models.Question) (models.Questionnaire, error) {
+	var qn models.Questionnaire
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		current, err := latestQuestionnaire(tx, shelterID)
+		if err != nil {
+			return err
+		}
+
+		qn = models.Questionnaire{
+			ShelterID:   shelterID,
+			Version:     1,
+			Questions:   questions,
+			CreatedByID: userID,
+		}
+		if current != nil {
+			qn.Version = current.Version + 1
+		}
+		return tx.Create(&qn).Error
+	})
+	if err != nil && qn.Version > 0 {
+		// the failed transaction can't be queried any more, so look again
+		var taken int64
+		if database.DB.Model(&models.Questionnaire{}).
+			Where("shelter_id = ? AND version = ?", shelterID, qn.Version).
+			Count(&taken).Error == nil && taken > 0 {
+			return qn, errQuestionnaireConflict
+		}
+	}
+	return qn, err
+}
+
+// respondPublishError answers for a failed publishQuestionnaire.
+func respondPublishError(c *gin.Context, err error) {
+	if errors.Is(err, errQuestionnaireConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to publish questionnaire"})
+}
+
+// GET /shelters/:id/questionnaire
+// The form applicants fill in when applying for one of the shelter's pets.
+func GetActiveQuestionnaire(c *gin.Context) {
+	shelterID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shelter id"})
+		return
+	}
+
+	qn, err := activeQuestionnaire(database.DB, shelterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch questionnaire"})
+		return
+	}
+	if qn == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "shelter has no questionnaire"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"questionnaire": qn})
+}
+
+// GET /shelters/:id/questionnaires
+// Every version, newest first.
+func GetQuestionnaireVersions(c *gin.Context) {
+	shelterID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shelter id"})
+		return
+	}
+
+	if !authorizeShelter(c, shelterID, models.PermManageQuestionnaires) {
+		return
+	}
+
+	var versions []models.Questionnaire
+	if err := database.DB.Where("shelter_id = ?", shelterID).
+		Order("version DESC").
+		Find(&versions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch questionnaires"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"questionnaires": versions})
+}
+
+// POST /shelters/:id/questionnaires
+// Publishes a new version, which applies to applications from now on.
+func CreateQuestionnaire(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	shelterID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shelter id"})
+		return
+	}
+
+	if err := database.DB.First(&models.Shelter{}, shelterID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "shelter not found"})
+		return
+	}
+	if !authorizeShelter(c, shelterID, models.PermManageQuestionnaires) {
+		return
+	}
+
+	var req createQuestionnaireRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if err := models.ValidateQuestions(req.Questions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	qn, err := publishQuestionnaire(shelterID, userID, req.Questions)
+	if err != nil {
+		respondPublishError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"questionnaire": qn})
+}
+
+// DELETE /shelters/:id/questionnaire
+// Turns the questionnaire off by publishing an empty version, so the
+// history shows when it happened. Publishing questions again turns it
+// back on.
+func DisableQuestionnaire(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	shelterID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shelter id"})
+		return
+	}
+
+	if !authorizeShelter(c, shelterID, models.PermManageQuestionnaires) {
+		return
+	}
+
+	qn, err := activeQuestionnaire(database.DB, shelterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch questionnaire"})
+		return
+	}
+	if qn == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "shelter has no questionnaire"})
+		return
+	}
+
+	if _, err := publishQuestionnaire(shelterID, userID, []models.Question{}); err != nil {
+		respondPublishError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
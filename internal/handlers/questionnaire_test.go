@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// Applications are checked against the shelter's latest questionnaire and keep their answers
+func TestQuestionnaire_ValidatesAndStoresAnswers(t *testing.T) {
+	owner, ownerSession := createProfileUser(t, "questionnaire-owner@test.com")
+	shelter := models.Shelter{Name: "Thorough Shelter", OwnerUserID: owner.ID}
+	database.DB.Create(&shelter)
+	pet := models.Pet{Name: "Vetted", Species: "Dog", ShelterID: shelter.ID, Status: models.PetStatusAvailable}
+	database.DB.Create(&pet)
+	basePath := "/shelters/" + strconv.Itoa(int(shelter.ID))
+
+	w := doJSON("POST", basePath+"/questionnaires", ownerSession.AccessToken, gin.H{"questions": []gin.H{
+		{"id": "housing", "label": "Housing", "type": "choice"},
+	}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	v1 := []gin.H{{"id": "experience", "label": "Experience with dogs", "type": "text"}}
+	w = doJSON("POST", basePath+"/questionnaires", ownerSession.AccessToken, gin.H{"questions": v1})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	v2 := []gin.H{
+		{"id": "housing", "label": "Housing type", "type": "choice", "required": true, "options": []string{"house", "apartment"}},
+		{"id": "landlord_ok", "label": "Landlord permits pets", "type": "yes_no", "required": true},
+		{"id": "other_pets", "label": "Other pets", "type": "text"},
+	}
+	w = doJSON("POST", basePath+"/questionnaires", ownerSession.AccessToken, gin.H{"questions": v2})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = doJSON("GET", basePath+"/questionnaire", "", nil)
+	var active struct {
+		Questionnaire models.Questionnaire `json:"questionnaire"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &active)
+	assert.Equal(t, 2, active.Questionnaire.Version)
+	assert.Len(t, active.Questionnaire.Questions, 3)
+
+	adopter, adopterSession := createProfileUser(t, "questionnaire-adopter@test.com")
+	now := time.Now()
+	database.DB.Model(&adopter).Update("email_verified_at", &now)
+	applyPath := "/adoptions/" + strconv.Itoa(int(pet.ID)) + "/apply"
+
+	w = doJSON("POST", applyPath, adopterSession.AccessToken, gin.H{"answers": gin.H{"housing": "castle", "pets": "none"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var invalid struct {
+		Fields map[string]string `json:"fields"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &invalid)
+	assert.Contains(t, invalid.Fields, "housing")
+	assert.Equal(t, "required", invalid.Fields["landlord_ok"])
+	assert.Equal(t, "unknown question", invalid.Fields["pets"])
+
+	w = doJSON("POST", applyPath, adopterSession.AccessToken, gin.H{
+		"message": "Hello!",
+		"answers": gin.H{"housing": "apartment", "landlord_ok": true},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var stored models.AdoptionRequest
+	database.DB.Where("user_id = ? AND pet_id = ?", adopter.ID, pet.ID).First(&stored)
+	if assert.NotNil(t, stored.QuestionnaireID) {
+		assert.Equal(t, active.Questionnaire.ID, *stored.QuestionnaireID)
+	}
+	assert.Equal(t, true, stored.Answers["landlord_ok"])
+
+	w = doJSON("GET", "/adoptions/my", adopterSession.AccessToken, nil)
+	assert.Contains(t, w.Body.String(), `"housing":"apartment"`)
+
+	// only staff see the version history
+	w = doJSON("GET", basePath+"/questionnaires", adopterSession.AccessToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON("GET", basePath+"/questionnaires", ownerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// turning the questionnaire off publishes an empty version
+	w = doJSON("DELETE", basePath+"/questionnaire", adopterSession.AccessToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON("DELETE", basePath+"/questionnaire", ownerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = doJSON("DELETE", basePath+"/questionnaire", ownerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON("GET", basePath+"/questionnaire", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	other := models.Pet{Name: "Unvetted", Species: "Dog", ShelterID: shelter.ID, Status: models.PetStatusAvailable}
+	database.DB.Create(&other)
+	w = doJSON("POST", "/adoptions/"+strconv.Itoa(int(other.ID))+"/apply", adopterSession.AccessToken, nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var versions int64
+	database.DB.Model(&models.Questionnaire{}).Where("shelter_id = ?", shelter.ID).Count(&versions)
+	assert.Equal(t, int64(3), versions)
+}
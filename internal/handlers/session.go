@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"pet-adoption-api/internal/auth"
+	"pet-adoption-api/internal/config"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const refreshTokenDuration = 30 * 24 * time.Hour
+
+var (
+	errRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+	errRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+type tokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+func (p tokenPair) response() gin.H {
+	return gin.H{
+		"token":         p.AccessToken,
+		"refresh_token": p.RefreshToken,
+		"expires_in":    int64(jwtManager.TokenDuration().Seconds()),
+	}
+}
+
+// startSession opens a new session (token family) for the user and returns
+// its first access + refresh token pair. mfa records whether the user proved
+// a second factor; it is carried over to every token of the session.
+func startSession(user models.User, mfa bool) (tokenPair, error) {
+	sessionID, err := auth.NewID()
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	var pair tokenPair
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		session := models.Session{ID: sessionID, UserID: user.ID, MFA: mfa}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		pair, err = issueTokens(tx, session, user)
+		return err
+	})
+	return pair, err
+}
+
+// issueTokens stores a fresh refresh token for the session and signs a
+// matching access token.
+func issueTokens(tx *gorm.DB, session models.Session, user models.User) (tokenPair, error) {
+	refresh, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	rt := models.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(refreshTokenDuration),
+	}
+	if err := tx.Create(&rt).Error; err != nil {
+		return tokenPair{}, err
+	}
+
+	access, err := jwtManager.Generate(auth.UserClaims{
+		UserID:    user.ID,
+		Role:      string(user.Role),
+		SessionID: session.ID,
+		MFA:       session.MFA,
+	})
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	return tokenPair{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+// rotateRefreshToken exchanges a refresh token for a new pair. Presenting a
+// token that was already exchanged means it has leaked, so the whole session
+// is revoked.
+func rotateRefreshToken(refresh string) (tokenPair, error) {
+	var pair tokenPair
+	var reused bool
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var rt models.RefreshToken
+		if err := tx.Preload("Session").Preload("Session.User").
+			Where("token_hash = ?", auth.HashToken(refresh)).
+			First(&rt).Error; err != nil {
+			return errRefreshTokenInvalid
+		}
+
+		if rt.Session.RevokedAt != nil {
+			return errRefreshTokenInvalid
+		}
+
+		if rt.UsedAt != nil {
+			reused = true
+			return nil
+		}
+
+		if time.Now().After(rt.ExpiresAt) {
+			return errRefreshTokenInvalid
+		}
+
+		// sessions opened before the 2FA policy was switched on can't be extended
+		if config.Require2FAForPrivileged && rt.Session.User.Role.IsPrivileged() && !rt.Session.MFA {
+			return errRefreshTokenInvalid
+		}
+
+		// conditional update so two concurrent refreshes cannot both win
+		now := time.Now()
+		res := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", rt.ID).
+			Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			reused = true
+			return nil
+		}
+
+		var err error
+		pair, err = issueTokens(tx, rt.Session, rt.Session.User)
+		return err
+	})
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	if reused {
+		var rt models.RefreshToken
+		if err := database.DB.Where("token_hash = ?", auth.HashToken(refresh)).First(&rt).Error; err != nil {
+			return tokenPair{}, err
+		}
+		// the client must not be told the family is revoked unless it is
+		if err := revokeSession(database.DB, rt.SessionID); err != nil {
+			log.Printf("failed to revoke session %s after refresh token reuse: %v", rt.SessionID, err)
+			return tokenPair{}, err
+		}
+		return tokenPair{}, errRefreshTokenReused
+	}
+
+	return pair, nil
+}
+
+// revokeSession marks a session as revoked; its access and refresh tokens stop working.
+func revokeSession(tx *gorm.DB, sessionID string) error {
+	return tx.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// revokeUserSessions logs the user out everywhere.
+func revokeUserSessions(tx *gorm.DB, userID uint) error {
+	return tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// revokeOtherSessions logs the user out everywhere except the given session.
+func revokeOtherSessions(tx *gorm.DB, userID uint, keepSessionID string) error {
+	return tx.Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepSessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// POST /auth/refresh
+func Refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	pair, err := rotateRefreshToken(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, errRefreshTokenInvalid) || errors.Is(err, errRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pair.response())
+}
+
+// POST /auth/logout
+func Logout(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	var rt models.RefreshToken
+	if err := database.DB.Where("token_hash = ?", auth.HashToken(req.RefreshToken)).First(&rt).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errRefreshTokenInvalid.Error()})
+		return
+	}
+
+	if err := revokeSession(database.DB, rt.SessionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
@@ -45,18 +45,31 @@ type createShelterRequest struct {
 	Name    string `json:"name" binding:"required"`
 	Address string `json:"address"`
 	Phone   string `json:"phone"`
-	// Optional: owner_user_id; or we can assign current user if role == shelter
-	OwnerUserID uint `json:"owner_user_id" binding:"required"`
+	// Optional: defaults to the current user. Only admins may pick someone else.
+	OwnerUserID uint `json:"owner_user_id"`
 }
 
-// POST /shelters  (admin only in routes)
+// POST /shelters  (shelter users for themselves, admin for anyone)
 func CreateShelter(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
 	var req createShelterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
 		return
 	}
 
+	if req.OwnerUserID == 0 {
+		req.OwnerUserID = userID
+	}
+	if req.OwnerUserID != userID && currentRole(c) != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "shelter users can only create shelters they own"})
+		return
+	}
+
 	shelter := models.Shelter{
 		Name:        req.Name,
 		Address:     req.Address,
@@ -78,6 +91,8 @@ type updateShelterRequest struct {
 	Name    string `json:"name"`
 	Address string `json:"address"`
 	Phone   string `json:"phone"`
+	// omitted keeps the current TTL; 0 goes back to the server default
+	AdoptionRequestTTLDays *int `json:"adoption_request_ttl_days"`
 }
 
 // PUT /shelters/:id
@@ -101,10 +116,17 @@ func UpdateShelter(c *gin.Context) {
 		return
 	}
 
+	if req.AdoptionRequestTTLDays != nil && !validTTLDays(c, *req.AdoptionRequestTTLDays) {
+		return
+	}
+
 	// Update fields from request
 	shelter.Name = req.Name
 	shelter.Address = req.Address
 	shelter.Phone = req.Phone
+	if req.AdoptionRequestTTLDays != nil {
+		shelter.AdoptionRequestTTLDays = *req.AdoptionRequestTTLDays
+	}
 	shelter.UpdatedAt = time.Now()
 
 	if err := database.DB.Save(&shelter).Error; err != nil {
@@ -115,6 +137,59 @@ func UpdateShelter(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"shelter": shelter})
 }
 
+// validTTLDays checks an adoption request TTL and writes a 400 when it is
+// out of range.
+func validTTLDays(c *gin.Context, days int) bool {
+	if days < 0 || days > models.MaxAdoptionRequestTTLDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "adoption_request_ttl_days must be between 0 and 365"})
+		return false
+	}
+	return true
+}
+
+type shelterSettingsRequest struct {
+	// 0 goes back to the server default
+	AdoptionRequestTTLDays *int `json:"adoption_request_ttl_days" binding:"required"`
+}
+
+// PATCH /shelters/:id/settings
+// Lets the shelter's owners and managers tune how it handles adoptions.
+func UpdateShelterSettings(c *gin.Context) {
+	shelterID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shelter id"})
+		return
+	}
+
+	var shelter models.Shelter
+	if err := database.DB.First(&shelter, shelterID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "shelter not found"})
+		return
+	}
+	if !authorizeShelter(c, shelterID, models.PermManageSettings) {
+		return
+	}
+
+	var req shelterSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "adoption_request_ttl_days is required"})
+		return
+	}
+	if !validTTLDays(c, *req.AdoptionRequestTTLDays) {
+		return
+	}
+
+	if err := database.DB.Model(&shelter).Updates(map[string]interface{}{
+		"adoption_request_ttl_days": *req.AdoptionRequestTTLDays,
+		"updated_at":                time.Now(),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update shelter settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shelter": shelter})
+}
+
 // DELETE /shelters/:id
 func DeleteShelter(c *gin.Context) {
 	idStr := c.Param("id")
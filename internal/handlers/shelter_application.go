@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type shelterApplicationRequest struct {
+	ShelterName string `json:"shelter_name" binding:"required"`
+	Address     string `json:"address"`
+	Phone       string `json:"phone"`
+	Message     string `json:"message"`
+}
+
+// POST /shelter-applications
+func ApplyForShelterOwnership(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if currentRole(c) != models.RoleUser {
+		c.JSON(http.StatusConflict, gin.H{"error": "account already has an elevated role"})
+		return
+	}
+
+	var req shelterApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	var pending int64
+	database.DB.Model(&models.ShelterApplication{}).
+		Where("user_id = ? AND status = ?", userID, models.ShelterApplicationPending).
+		Count(&pending)
+	if pending > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "you already have a pending shelter application"})
+		return
+	}
+
+	app := models.ShelterApplication{
+		UserID:      userID,
+		ShelterName: req.ShelterName,
+		Address:     req.Address,
+		Phone:       req.Phone,
+		Message:     req.Message,
+		Status:      models.ShelterApplicationPending,
+	}
+
+	if err := database.DB.Create(&app).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create shelter application"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"shelter_application": app})
+}
+
+// GET /shelter-applications/my
+func GetMyShelterApplications(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var apps []models.ShelterApplication
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&apps).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch shelter applications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shelter_applications": apps})
+}
+
+// GET /admin/shelter-applications?status=pending
+func ListShelterApplications(c *gin.Context) {
+	var apps []models.ShelterApplication
+
+	query := database.DB.Model(&models.ShelterApplication{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Order("created_at").Find(&apps).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch shelter applications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shelter_applications": apps})
+}
+
+type reviewShelterApplicationRequest struct {
+	CreateShelter bool   `json:"create_shelter"`
+	ReviewNote    string `json:"review_note"`
+}
+
+var errApplicationNotPending = errors.New("shelter application is not pending")
+
+// PATCH /admin/shelter-applications/:id/approve
+func ApproveShelterApplication(c *gin.Context) {
+	reviewShelterApplication(c, models.ShelterApplicationApproved)
+}
+
+// PATCH /admin/shelter-applications/:id/reject
+func RejectShelterApplication(c *gin.Context) {
+	reviewShelterApplication(c, models.ShelterApplicationRejected)
+}
+
+// helper: approve / reject. Approval promotes the applicant to RoleShelter
+// and, if asked, creates the shelter they applied for.
+func reviewShelterApplication(c *gin.Context, newStatus models.ShelterApplicationStatus) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shelter application id"})
+		return
+	}
+
+	var req reviewShelterApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		// body is optional
+		req = reviewShelterApplicationRequest{}
+	}
+
+	var app models.ShelterApplication
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("User").First(&app, id).Error; err != nil {
+			return err
+		}
+		if app.Status != models.ShelterApplicationPending {
+			return errApplicationNotPending
+		}
+
+		now := time.Now()
+		app.Status = newStatus
+		app.ReviewNote = req.ReviewNote
+		app.ReviewedByID = &adminID
+		app.ReviewedAt = &now
+
+		if newStatus == models.ShelterApplicationApproved {
+			// never demote an admin who happens to apply
+			if app.User.Role == models.RoleUser {
+				if err := tx.Model(&app.User).Update("role", models.RoleShelter).Error; err != nil {
+					return err
+				}
+			}
+
+			if req.CreateShelter {
+				shelter := models.Shelter{
+					Name:        app.ShelterName,
+					Address:     app.Address,
+					Phone:       app.Phone,
+					OwnerUserID: app.UserID,
+				}
+				if err := tx.Create(&shelter).Error; err != nil {
+					return err
+				}
+				app.ShelterID = &shelter.ID
+			}
+		}
+
+		return tx.Omit("User").Save(&app).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "shelter application not found"})
+		case errors.Is(err, errApplicationNotPending):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to review shelter application"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shelter_application": app})
+}
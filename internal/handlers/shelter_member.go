@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"pet-adoption-api/internal/worker"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// hasShelterPermission reports whether the user's active membership in the
+// shelter grants perm. Admins may do anything.
+func hasShelterPermission(userID uint, role models.Role, shelterID uint, perm models.ShelterPermission) bool {
+	if role == models.RoleAdmin {
+		return true
+	}
+
+	var m models.ShelterMember
+	if err := database.DB.
+		Where("shelter_id = ? AND user_id = ? AND status = ?", shelterID, userID, models.MembershipActive).
+		First(&m).Error; err != nil {
+		return false
+	}
+	return m.Role.Can(perm)
+}
+
+// canActForShelter checks the caller's permission in a shelter. API keys
+// only act for their own shelter and need the matching scope.
+func canActForShelter(c *gin.Context, userID uint, shelterID uint, perm models.ShelterPermission) bool {
+	if key := currentAPIKey(c); key != nil {
+		scope, ok := models.ScopeFor(perm)
+		return ok && key.ShelterID == shelterID && key.HasScope(scope)
+	}
+	return hasShelterPermission(userID, currentRole(c), shelterID, perm)
+}
+
+// authorizeShelter checks the caller's permission in a shelter and writes a
+// 403 when it is missing.
+func authorizeShelter(c *gin.Context, shelterID uint, perm models.ShelterPermission) bool {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return false
+	}
+
+	if !canActForShelter(c, userID, shelterID, perm) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you don't have permission for this shelter"})
+		return false
+	}
+	return true
+}
+
+// shelterIDsWithPermission is a subquery of the shelters where the user
+// holds perm, for use in "shelter_id IN (?)" filters.
+func shelterIDsWithPermission(userID uint, perm models.ShelterPermission) *gorm.DB {
+	return database.DB.Model(&models.ShelterMember{}).
+		Select("shelter_id").
+		Where("user_id = ? AND status = ? AND role IN ?", userID, models.MembershipActive, models.ShelterRolesWith(perm))
+}
+
+// GET /shelters/:id/members
+func GetShelterMembers(c *gin.Context) {
+	shelterID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shelter id"})
+		return
+	}
+
+	if !authorizeShelter(c, shelterID, models.PermViewAdoptions) {
+		return
+	}
+
+	var members []models.ShelterMember
+	if err := database.DB.Where("shelter_id = ?", shelterID).Order("created_at").Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+type inviteMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required"`
+}
+
+// POST /shelters/:id/members
+func InviteShelterMember(c *gin.Context) {
+	inviterID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	shelterID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shelter id"})
+		return
+	}
+
+	var req inviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	role, err := models.ParseShelterRole(req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown shelter role"})
+		return
+	}
+
+	var shelter models.Shelter
+	if err := database.DB.First(&shelter, shelterID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "shelter not found"})
+		return
+	}
+
+	if !authorizeShelter(c, shelterID, models.PermManageMembers) {
+		return
+	}
+
+	var invitee models.User
+	if err := database.DB.Where("email = ?", req.Email).First(&invitee).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no account with this email"})
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.ShelterMember{}).
+		Where("shelter_id = ? AND user_id = ?", shelterID, invitee.ID).
+		Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "user is already a member or invited"})
+		return
+	}
+
+	member := models.ShelterMember{
+		ShelterID:   shelterID,
+		UserID:      invitee.ID,
+		Role:        role,
+		Status:      models.MembershipInvited,
+		InvitedByID: &inviterID,
+	}
+	if err := database.DB.Create(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to invite member"})
+		return
+	}
+
+	sendNotification(worker.Notification{
+		To:      invitee.Email,
+		Subject: "You've been invited to join " + shelter.Name,
+		Body: "You have been invited to join " + shelter.Name + " as " + string(role) + ".\n\n" +
+			"Accept the invitation in the app, or ignore this email to decline.",
+	})
+
+	c.JSON(http.StatusCreated, gin.H{"member": member})
+}
+
+// POST /shelters/:id/members/accept
+func AcceptShelterInvite(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	shelterID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shelter id"})
+		return
+	}
+
+	var member models.ShelterMember
+	if err := database.DB.
+		Where("shelter_id = ? AND user_id = ?", shelterID, userID).
+		First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invitation not found"})
+		return
+	}
+
+	if member.Status != models.MembershipInvited {
+		c.JSON(http.StatusConflict, gin.H{"error": "invitation already accepted"})
+		return
+	}
+
+	now := time.Now()
+	member.Status = models.MembershipActive
+	member.AcceptedAt = &now
+	if err := database.DB.Save(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to accept invitation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"member": member})
+}
+
+var errLastOwner = errors.New("a shelter must keep at least one owner")
+
+// DELETE /shelters/:id/members/:userID
+// Owners can remove anyone; every member can remove themselves (leave or
+// decline an invitation).
+func RemoveShelterMember(c *gin.Context) {
+	callerID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	shelterID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shelter id"})
+		return
+	}
+
+	memberUserID, ok := parseIDParam(c, "userID")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if memberUserID != callerID && !authorizeShelter(c, shelterID, models.PermManageMembers) {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var member models.ShelterMember
+		if err := tx.Where("shelter_id = ? AND user_id = ?", shelterID, memberUserID).First(&member).Error; err != nil {
+			return err
+		}
+
+		if member.Role == models.ShelterRoleOwner && member.Status == models.MembershipActive {
+			var owners int64
+			tx.Model(&models.ShelterMember{}).
+				Where("shelter_id = ? AND role = ? AND status = ?", shelterID, models.ShelterRoleOwner, models.MembershipActive).
+				Count(&owners)
+			if owners <= 1 {
+				return errLastOwner
+			}
+		}
+
+		return tx.Delete(&member).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "member not found"})
+		case errors.Is(err, errLastOwner):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove member"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
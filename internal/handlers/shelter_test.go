@@ -6,12 +6,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"pet-adoption-api/internal/auth"
 	"pet-adoption-api/internal/database"
 	"pet-adoption-api/internal/middleware"
 	"pet-adoption-api/internal/models"
 	"pet-adoption-api/internal/worker"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -34,38 +36,122 @@ func TestMain(m *testing.M) {
 	database.DB = db
 
 	// Run migrations for all models
-	db.AutoMigrate(&models.User{}, &models.Pet{}, &models.Shelter{}, &models.AdoptionRequest{})
+	database.Migrate(db)
 
 	// Set up the router
 	gin.SetMode(gin.TestMode)
 	testRouter = gin.Default()
 
-	// Initialize auth and middleware
-	InitAuth()
-	middleware.InitAuthMiddleware()
+	// Initialize auth and middleware with a shared HS256 test key
+	keys, _ := auth.NewKeyring(auth.NewHMACKey("test", []byte("test-secret")))
+	jm := auth.NewJWTManager(keys, 15*time.Minute)
+	InitAuth(jm)
+	middleware.InitAuthMiddleware(jm)
 
 	// Initialize a dummy channel for adoption events
 	AdoptionEvents = make(chan worker.AdoptionEvent, 10)
+	Notifications = make(chan worker.Notification, 10)
 
 	// --- Centralized Route Setup ---
 	authRoutes := testRouter.Group("/auth")
 	{
 		authRoutes.POST("/register", Register)
 		authRoutes.POST("/login", Login)
+		authRoutes.POST("/refresh", Refresh)
+		authRoutes.POST("/logout", Logout)
+		authRoutes.POST("/password/forgot", ForgotPassword)
+		authRoutes.POST("/password/reset", ResetPassword)
+		authRoutes.POST("/verify-email", VerifyEmail)
+		authRoutes.POST("/confirm-email", ConfirmEmailChange)
+		authRoutes.POST("/verify-email/resend", middleware.AuthMiddleware(), ResendVerificationEmail)
+		authRoutes.POST("/login/2fa", LoginWithTOTP)
+		authRoutes.POST("/2fa/enroll", middleware.MFAEnrollmentAuth(), EnrollTOTP)
+		authRoutes.POST("/2fa/confirm", middleware.MFAEnrollmentAuth(), ConfirmTOTP)
+		authRoutes.POST("/2fa/disable", middleware.AuthMiddleware(), DisableTOTP)
+		authRoutes.POST("/2fa/recovery-codes", middleware.AuthMiddleware(), RegenerateRecoveryCodes)
+		authRoutes.GET("/oidc/login", OIDCLogin)
+		authRoutes.GET("/oidc/callback", OIDCCallback)
+	}
+
+	meRoutes := testRouter.Group("/me", middleware.AuthMiddleware())
+	{
+		meRoutes.GET("", GetMe)
+		meRoutes.PATCH("", UpdateMe)
+		meRoutes.DELETE("", DeleteMe)
+		meRoutes.POST("/password", ChangePassword)
+		meRoutes.GET("/export", ExportMyData)
+		meRoutes.POST("/erasure", RequestErasure)
+	}
+
+	petRoutes := testRouter.Group("/pets")
+	{
+		petRoutes.GET("/", GetPets)
+		petRoutes.GET("/:id", GetPetByID)
+		petRoutes.POST("/", middleware.AuthMiddleware(models.ScopePetsWrite), CreatePet)
+		petRoutes.PUT("/:id", middleware.AuthMiddleware(models.ScopePetsWrite), UpdatePet)
+		petRoutes.DELETE("/:id", middleware.AuthMiddleware(models.ScopePetsWrite), DeletePet)
+		petRoutes.POST("/:id/hold", middleware.AuthMiddleware(models.ScopeAdoptionsWrite), PlacePetHold)
+		petRoutes.DELETE("/:id/hold", middleware.AuthMiddleware(models.ScopeAdoptionsWrite), ReleasePetHold)
 	}
 
 	shelterRoutes := testRouter.Group("/shelters")
 	{
 		shelterRoutes.GET("/", GetShelters)
 		shelterRoutes.GET("/:id", GetShelterByID)
-		shelterRoutes.POST("/", middleware.AuthMiddleware(), middleware.AdminOnly(), CreateShelter)
+		shelterRoutes.POST("/", middleware.AuthMiddleware(), middleware.ShelterOnly(), CreateShelter)
 		shelterRoutes.PUT("/:id", middleware.AuthMiddleware(), middleware.AdminOnly(), UpdateShelter)
 		shelterRoutes.DELETE("/:id", middleware.AuthMiddleware(), middleware.AdminOnly(), DeleteShelter)
+		shelterRoutes.PATCH("/:id/settings", middleware.AuthMiddleware(), UpdateShelterSettings)
+		shelterRoutes.GET("/:id/members", middleware.AuthMiddleware(), GetShelterMembers)
+		shelterRoutes.POST("/:id/members", middleware.AuthMiddleware(), InviteShelterMember)
+		shelterRoutes.POST("/:id/members/accept", middleware.AuthMiddleware(), AcceptShelterInvite)
+		shelterRoutes.DELETE("/:id/members/:userID", middleware.AuthMiddleware(), RemoveShelterMember)
+		shelterRoutes.GET("/:id/api-keys", middleware.AuthMiddleware(), GetAPIKeys)
+		shelterRoutes.POST("/:id/api-keys", middleware.AuthMiddleware(), CreateAPIKey)
+		shelterRoutes.DELETE("/:id/api-keys/:keyID", middleware.AuthMiddleware(), RevokeAPIKey)
+		shelterRoutes.GET("/:id/questionnaire", GetActiveQuestionnaire)
+		shelterRoutes.GET("/:id/questionnaires", middleware.AuthMiddleware(), GetQuestionnaireVersions)
+		shelterRoutes.POST("/:id/questionnaires", middleware.AuthMiddleware(), CreateQuestionnaire)
+		shelterRoutes.DELETE("/:id/questionnaire", middleware.AuthMiddleware(), DisableQuestionnaire)
+	}
+
+	adoptionRoutes := testRouter.Group("/adoptions")
+	{
+		adoptionRoutes.POST("/:id/apply", middleware.AuthMiddleware(), middleware.VerifiedEmailOnly(), ApplyForAdoption)
+		adoptionRoutes.GET("/my", middleware.AuthMiddleware(), GetMyAdoptions)
+		adoptionRoutes.PATCH("/:id/cancel", middleware.AuthMiddleware(), CancelAdoption)
+		adoptionRoutes.GET("/:id", middleware.AuthMiddleware(models.ScopeAdoptionsRead), GetAdoptionRequest)
+		adoptionRoutes.GET("/shelter", middleware.AuthMiddleware(models.ScopeAdoptionsRead), GetShelterAdoptions)
+		adoptionRoutes.PATCH("/:id/approve", middleware.AuthMiddleware(models.ScopeAdoptionsWrite), ApproveAdoption)
+		adoptionRoutes.PATCH("/:id/reject", middleware.AuthMiddleware(models.ScopeAdoptionsWrite), RejectAdoption)
+		adoptionRoutes.GET("/:id/messages", middleware.AuthMiddleware(models.ScopeAdoptionsRead), GetAdoptionMessages)
+		adoptionRoutes.POST("/:id/messages", middleware.AuthMiddleware(models.ScopeAdoptionsWrite), PostAdoptionMessage)
+		adoptionRoutes.POST("/:id/messages/read", middleware.AuthMiddleware(), MarkAdoptionMessagesRead)
+		adoptionRoutes.GET("/:id/history", middleware.AuthMiddleware(models.ScopeAdoptionsRead), GetAdoptionHistory)
+		adoptionRoutes.POST("/:id/notes", middleware.AuthMiddleware(models.ScopeAdoptionsWrite), PostAdoptionNote)
+	}
+
+	shelterAppRoutes := testRouter.Group("/shelter-applications", middleware.AuthMiddleware())
+	{
+		shelterAppRoutes.POST("/", ApplyForShelterOwnership)
 	}
 
-	adoptionRoutes := testRouter.Group("/adoptions", middleware.AuthMiddleware())
+	adminRoutes := testRouter.Group("/admin", middleware.AuthMiddleware(), middleware.AdminOnly())
 	{
-		adoptionRoutes.POST("/:petID/apply", ApplyForAdoption)
+		adminRoutes.PATCH("/shelter-applications/:id/approve", ApproveShelterApplication)
+		adminRoutes.PATCH("/shelter-applications/:id/reject", RejectShelterApplication)
+		adminRoutes.GET("/users", ListUsers)
+		adminRoutes.GET("/users/:id", GetUser)
+		adminRoutes.POST("/users/:id/suspend", SuspendUser)
+		adminRoutes.POST("/users/:id/reactivate", ReactivateUser)
+		adminRoutes.PATCH("/users/:id/role", ChangeUserRole)
+		adminRoutes.POST("/users/:id/logout", ForceLogoutUser)
+		adminRoutes.POST("/users/:id/impersonate", ImpersonateUser)
+		adminRoutes.POST("/users/:id/unlock", UnlockUser)
+		adminRoutes.GET("/audit-logs", ListAuditLogs)
+		adminRoutes.GET("/erasure-requests", ListErasureRequests)
+		adminRoutes.PATCH("/erasure-requests/:id/approve", ApproveErasureRequest)
+		adminRoutes.PATCH("/erasure-requests/:id/reject", RejectErasureRequest)
 	}
 
 	// Create base test data (users, tokens, a shelter, a pet)
@@ -84,7 +170,8 @@ func TestMain(m *testing.M) {
 func createBaseTestData() {
 	// Create an admin user
 	hash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
-	adminUser := models.User{Name: "Admin User", Email: "admin@test.com", PasswordHash: string(hash), Role: "admin"}
+	verifiedAt := time.Now()
+	adminUser := models.User{Name: "Admin User", Email: "admin@test.com", PasswordHash: string(hash), Role: "admin", EmailVerifiedAt: &verifiedAt}
 	database.DB.Create(&adminUser)
 
 	// Create a shelter owner user
@@ -100,7 +187,8 @@ func createBaseTestData() {
 	database.DB.Create(&testPet) // This will have ID 1
 
 	// Generate token for the admin user
-	adminToken, _ = jwtManager.Generate(adminUser.ID, string(adminUser.Role))
+	pair, _ := startSession(adminUser, false)
+	adminToken = pair.AccessToken
 }
 
 // Test GetShelters endpoint
@@ -178,6 +266,38 @@ func TestUpdateShelter(t *testing.T) {
 	var response map[string]models.Shelter
 	json.Unmarshal(w.Body.Bytes(), &response)
 	assert.Equal(t, "Updated Base Shelter", response["shelter"].Name)
+
+	// TTLs beyond a year are refused rather than overflowing in the expiry job
+	for _, days := range []int{-1, 366, 213504} {
+		w = doJSON("PUT", "/shelters/1", adminToken, gin.H{"name": "Updated Base Shelter", "adoption_request_ttl_days": days})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+}
+
+// Owners and managers set their shelter's adoption request TTL; volunteers can't
+func TestUpdateShelterSettings(t *testing.T) {
+	owner, ownerSession := createProfileUser(t, "settings-owner@test.com")
+	shelter := models.Shelter{Name: "Configurable Shelter", OwnerUserID: owner.ID}
+	database.DB.Create(&shelter)
+	volunteer, volunteerSession := createProfileUser(t, "settings-volunteer@test.com")
+	database.DB.Create(&models.ShelterMember{ShelterID: shelter.ID, UserID: volunteer.ID, Role: models.ShelterRoleVolunteer, Status: models.MembershipActive})
+	path := "/shelters/" + strconv.Itoa(int(shelter.ID)) + "/settings"
+
+	w := doJSON("PATCH", path, ownerSession.AccessToken, gin.H{"adoption_request_ttl_days": 14})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"adoption_request_ttl_days":14`)
+	database.DB.First(&shelter, shelter.ID)
+	assert.Equal(t, 14, shelter.AdoptionRequestTTLDays)
+
+	w = doJSON("PATCH", path, ownerSession.AccessToken, gin.H{"adoption_request_ttl_days": 400})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON("PATCH", path, ownerSession.AccessToken, gin.H{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON("PATCH", path, volunteerSession.AccessToken, gin.H{"adoption_request_ttl_days": 1})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	database.DB.First(&shelter, shelter.ID)
+	assert.Equal(t, 14, shelter.AdoptionRequestTTLDays)
 }
 
 // Test DeleteShelter endpoint
@@ -198,3 +318,121 @@ func TestDeleteShelter(t *testing.T) {
 	err := database.DB.First(&deletedShelter, tempShelter.ID).Error
 	assert.NotNil(t, err)
 }
+
+// Staff invited to a shelter act according to their shelter role
+func TestShelterMembers_PermissionsFollowRole(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	manager := models.User{Name: "Manager", Email: "manager@test.com", PasswordHash: string(hash), Role: models.RoleUser}
+	volunteer := models.User{Name: "Volunteer", Email: "volunteer@test.com", PasswordHash: string(hash), Role: models.RoleUser}
+	adopter := models.User{Name: "Adopter", Email: "members-adopter@test.com", PasswordHash: string(hash), Role: models.RoleUser}
+	database.DB.Create(&manager)
+	database.DB.Create(&volunteer)
+	database.DB.Create(&adopter)
+
+	ownerSession, _ := startSession(shelterOwnerUser, false)
+	managerSession, _ := startSession(manager, false)
+	volunteerSession, _ := startSession(volunteer, false)
+
+	var shelter models.Shelter
+	database.DB.Where("owner_user_id = ?", shelterOwnerUser.ID).First(&shelter)
+	membersPath := "/shelters/" + strconv.Itoa(int(shelter.ID)) + "/members"
+
+	w := doJSON("POST", membersPath, ownerSession.AccessToken, gin.H{"email": manager.Email, "role": "manager"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w = doJSON("POST", membersPath, ownerSession.AccessToken, gin.H{"email": volunteer.Email, "role": "volunteer"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w = doJSON("POST", membersPath, ownerSession.AccessToken, gin.H{"email": adopter.Email, "role": "janitor"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	pet := models.Pet{Name: "Staff Pet", Species: "Dog", ShelterID: shelter.ID, Status: models.PetStatusAvailable}
+	database.DB.Create(&pet)
+	ar := models.AdoptionRequest{UserID: adopter.ID, PetID: pet.ID, Status: models.AdoptionStatusPending}
+	database.DB.Create(&ar)
+	approvePath := "/adoptions/" + strconv.Itoa(int(ar.ID)) + "/approve"
+
+	// invitations grant nothing until accepted
+	w = doJSON("PATCH", approvePath, managerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doJSON("POST", membersPath+"/accept", managerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON("POST", membersPath+"/accept", volunteerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// volunteers see requests but can't decide them
+	w = doJSON("GET", "/adoptions/shelter", volunteerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"pet_id":`+strconv.Itoa(int(pet.ID)))
+	w = doJSON("PATCH", approvePath, volunteerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// managers decide requests but don't manage staff
+	w = doJSON("POST", membersPath, managerSession.AccessToken, gin.H{"email": adopter.Email, "role": "volunteer"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON("PATCH", approvePath, managerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the last owner can't leave
+	w = doJSON("DELETE", membersPath+"/"+strconv.Itoa(int(shelterOwnerUser.ID)), ownerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doJSON("DELETE", membersPath+"/"+strconv.Itoa(int(volunteer.ID)), ownerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func doWithAPIKey(method, path, key string, payload interface{}) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(payload)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", key)
+	testRouter.ServeHTTP(w, req)
+	return w
+}
+
+// API keys act for their own shelter, within their scopes, until revoked
+func TestAPIKeys_ScopedToShelter(t *testing.T) {
+	ownerSession, _ := startSession(shelterOwnerUser, false)
+
+	var shelter models.Shelter
+	database.DB.Where("owner_user_id = ?", shelterOwnerUser.ID).First(&shelter)
+	keysPath := "/shelters/" + strconv.Itoa(int(shelter.ID)) + "/api-keys"
+
+	w := doJSON("POST", keysPath, ownerSession.AccessToken, gin.H{"name": "intake", "scopes": []string{"pets:fly"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON("POST", keysPath, ownerSession.AccessToken, gin.H{"name": "intake", "scopes": []string{"pets:write"}})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		APIKey models.APIKey `json:"api_key"`
+		Key    string        `json:"key"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	assert.NotEmpty(t, created.Key)
+
+	w = doWithAPIKey("POST", "/pets/", created.Key, gin.H{"shelter_id": shelter.ID, "name": "Synced", "species": "Dog"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var admin models.User
+	database.DB.Where("email = ?", "admin@test.com").First(&admin)
+	otherShelter := models.Shelter{Name: "Other Intake", OwnerUserID: admin.ID}
+	database.DB.Create(&otherShelter)
+	w = doWithAPIKey("POST", "/pets/", created.Key, gin.H{"shelter_id": otherShelter.ID, "name": "Synced", "species": "Dog"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// missing scope, and routes that don't take keys at all
+	w = doWithAPIKey("GET", "/adoptions/shelter", created.Key, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doWithAPIKey("GET", "/adoptions/my", created.Key, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var stored models.APIKey
+	database.DB.First(&stored, created.APIKey.ID)
+	assert.NotNil(t, stored.LastUsedAt)
+
+	w = doJSON("DELETE", keysPath+"/"+strconv.Itoa(int(created.APIKey.ID)), ownerSession.AccessToken, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = doWithAPIKey("POST", "/pets/", created.Key, gin.H{"shelter_id": shelter.ID, "name": "Late", "species": "Dog"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
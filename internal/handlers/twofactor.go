@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"pet-adoption-api/internal/auth"
+	"pet-adoption-api/internal/config"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	mfaChallengeDuration  = 5 * time.Minute
+	mfaEnrollmentDuration = 10 * time.Minute
+	recoveryCodeCount     = 10
+)
+
+var (
+	errSecondFactorInvalid = errors.New("invalid two-factor code")
+	errTOTPNotEnabled      = errors.New("two-factor authentication is not enabled")
+	errTOTPAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+)
+
+func totpIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "Pet Adoption API"
+}
+
+// verifySecondFactor accepts either a current TOTP code (each code only
+// once) or an unused recovery code, which is burned.
+func verifySecondFactor(tx *gorm.DB, user *models.User, code, recoveryCode string) error {
+	if user.TOTPSecret == "" {
+		return errTOTPNotEnabled
+	}
+
+	if code != "" {
+		step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now())
+		if !ok || step <= user.TOTPLastStep {
+			return errSecondFactorInvalid
+		}
+
+		res := tx.Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", user.ID, step).
+			Update("totp_last_step", step)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errSecondFactorInvalid
+		}
+		user.TOTPLastStep = step
+		return nil
+	}
+
+	if recoveryCode != "" {
+		res := tx.Model(&models.RecoveryCode{}).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, auth.HashToken(recoveryCode)).
+			Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errSecondFactorInvalid
+		}
+		return nil
+	}
+
+	return errSecondFactorInvalid
+}
+
+// generateRecoveryCodes replaces the user's recovery codes with a new set.
+func generateRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := auth.NewRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Create(&models.RecoveryCode{UserID: userID, CodeHash: auth.HashToken(code)}).Error; err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+func secondFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errSecondFactorInvalid):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, errTOTPNotEnabled), errors.Is(err, errTOTPAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify two-factor code"})
+	}
+}
+
+// POST /auth/2fa/enroll
+func EnrollTOTP(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if user.TOTPEnabledAt != nil {
+		secondFactorError(c, errTOTPAlreadyEnabled)
+		return
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate secret"})
+		return
+	}
+
+	// stays inactive until confirmed with a code
+	if err := database.DB.Model(&user).Updates(map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_last_step": 0,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": auth.TOTPURI(totpIssuer(), user.Email, secret),
+	})
+}
+
+type totpCodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// POST /auth/2fa/confirm
+func ConfirmTOTP(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req totpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	var user models.User
+	var codes []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		if user.TOTPEnabledAt != nil {
+			return errTOTPAlreadyEnabled
+		}
+		if err := verifySecondFactor(tx, &user, req.Code, ""); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&user).Update("totp_enabled_at", now).Error; err != nil {
+			return err
+		}
+		user.TOTPEnabledAt = &now
+
+		var err error
+		codes, err = generateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		secondFactorError(c, err)
+		return
+	}
+
+	resp := gin.H{"recovery_codes": codes}
+
+	// enrolling through the policy gate: the user just proved both factors
+	if purpose, _ := c.Get("tokenPurpose"); purpose == auth.PurposeMFAEnrollment {
+		pair, err := startSession(user, true)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+			return
+		}
+		resp = loginResponse(pair, user)
+		resp["recovery_codes"] = codes
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /auth/2fa/disable
+func DisableTOTP(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if config.Require2FAForPrivileged && currentRole(c).IsPrivileged() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "two-factor authentication is required for this account"})
+		return
+	}
+
+	var req totpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		if user.TOTPEnabledAt == nil {
+			return errTOTPNotEnabled
+		}
+		if err := verifySecondFactor(tx, &user, req.Code, req.RecoveryCode); err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		}).Error
+	})
+	if err != nil {
+		secondFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+// POST /auth/2fa/recovery-codes
+func RegenerateRecoveryCodes(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req totpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	var codes []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		if user.TOTPEnabledAt == nil {
+			return errTOTPNotEnabled
+		}
+		if err := verifySecondFactor(tx, &user, req.Code, ""); err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = generateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		secondFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+type loginTOTPRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+// POST /auth/login/2fa
+func LoginWithTOTP(c *gin.Context) {
+	var req loginTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	claims, err := jwtManager.Verify(req.ChallengeToken)
+	if err != nil || claims.Purpose != auth.PurposeMFAChallenge {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired challenge token"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, claims.UserID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired challenge token"})
+		return
+	}
+
+	if user.IsSuspended() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "account suspended"})
+		return
+	}
+
+	// codes are short, so guessing them is throttled like passwords
+	accountKey := accountThrottleKey(user.Email)
+	ipKey := ipThrottleKey(c.ClientIP())
+	if !checkLoginThrottle(c, ipKey, accountKey) {
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if user.TOTPEnabledAt == nil {
+			return errTOTPNotEnabled
+		}
+		return verifySecondFactor(tx, &user, req.Code, req.RecoveryCode)
+	})
+	if err != nil {
+		if errors.Is(err, errSecondFactorInvalid) {
+			recordFailedLogin(accountKey, ipKey)
+		}
+		secondFactorError(c, err)
+		return
+	}
+
+	pair, err := startSession(user, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	clearLoginThrottle(accountKey)
+	c.JSON(http.StatusOK, loginResponse(pair, user))
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"pet-adoption-api/internal/auth"
+	"pet-adoption-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var errUserTokenInvalid = errors.New("invalid or expired token")
+
+// issueUserToken creates a single-use token for the user, replacing any
+// unused tokens they still have for the same purpose. The plain token is
+// returned so it can be mailed out; only its hash is stored.
+func issueUserToken(tx *gorm.DB, userID uint, purpose models.TokenPurpose, ttl time.Duration) (string, error) {
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Delete(&models.UserToken{}).Error; err != nil {
+		return "", err
+	}
+
+	ut := models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := tx.Create(&ut).Error; err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// consumeUserToken marks a token as used and returns it. It fails with
+// errUserTokenInvalid for unknown, expired, already-used or wrong-purpose
+// tokens.
+func consumeUserToken(tx *gorm.DB, token string, purpose models.TokenPurpose) (models.UserToken, error) {
+	var ut models.UserToken
+	if err := tx.Where("token_hash = ? AND purpose = ?", auth.HashToken(token), purpose).
+		First(&ut).Error; err != nil {
+		return ut, errUserTokenInvalid
+	}
+
+	if ut.UsedAt != nil || time.Now().After(ut.ExpiresAt) {
+		return ut, errUserTokenInvalid
+	}
+
+	// conditional update so a token can only ever be redeemed once
+	now := time.Now()
+	res := tx.Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL", ut.ID).
+		Update("used_at", now)
+	if res.Error != nil {
+		return ut, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ut, errUserTokenInvalid
+	}
+
+	ut.UsedAt = &now
+	return ut, nil
+}
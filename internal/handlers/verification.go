@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+	"pet-adoption-api/internal/worker"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	emailVerificationTokenDuration = 24 * time.Hour
+	// minimum time between two verification emails for the same user
+	verificationResendInterval = time.Minute
+)
+
+// sendVerificationEmail issues a fresh verification token and mails it.
+func sendVerificationEmail(user models.User) error {
+	token, err := issueUserToken(database.DB, user.ID, models.TokenPurposeEmailVerification, emailVerificationTokenDuration)
+	if err != nil {
+		return err
+	}
+
+	sendNotification(worker.Notification{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: "Welcome! Please confirm your email address by opening the link below " +
+			"within the next 24 hours:\n\n" +
+			appURL("/verify-email?token="+url.QueryEscape(token)),
+	})
+	return nil
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// POST /auth/verify-email
+func VerifyEmail(c *gin.Context) {
+	var req verifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		ut, err := consumeUserToken(tx, req.Token, models.TokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.User{}).
+			Where("id = ? AND email_verified_at IS NULL", ut.UserID).
+			Update("email_verified_at", time.Now()).Error
+	})
+	if err != nil {
+		if errors.Is(err, errUserTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
+
+// POST /auth/verify-email/resend
+func ResendVerificationEmail(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "email already verified"})
+		return
+	}
+
+	// throttle: one email per interval
+	var last models.UserToken
+	err := database.DB.
+		Where("user_id = ? AND purpose = ?", user.ID, models.TokenPurposeEmailVerification).
+		Order("created_at DESC").
+		First(&last).Error
+	if err == nil {
+		if wait := time.Until(last.CreatedAt.Add(verificationResendInterval)); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "verification email sent recently, try again later"})
+			return
+		}
+	}
+
+	if err := sendVerificationEmail(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "verification email sent"})
+}
@@ -2,11 +2,12 @@ package middleware
 
 import (
 	"net/http"
-	"os"
 	"strings"
-	"time"
 
 	"pet-adoption-api/internal/auth"
+	"pet-adoption-api/internal/config"
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,41 +15,131 @@ import (
 var jwtManager *auth.JWTManager
 
 // InitAuthMiddleware should be called from main().
-func InitAuthMiddleware() {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "dev-secret"
-	}
-	jwtManager = auth.NewJWTManager(secret, 24*time.Hour)
+func InitAuthMiddleware(m *auth.JWTManager) {
+	jwtManager = m
 }
 
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware authenticates a Bearer token. Routes that list scopes also
+// accept a shelter API key (X-API-Key header) holding all of those scopes.
+func AuthMiddleware(scopes ...models.APIKeyScope) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing Authorization header"})
+		if c.GetHeader(apiKeyHeader) != "" {
+			if !authenticateAPIKey(c, scopes) {
+				return
+			}
+			c.Next()
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid Authorization header"})
+		if !authenticate(c, "") {
+			return
+		}
+		if adminID, ok := impersonatorID(c); ok {
+			serveImpersonated(c, adminID)
 			return
 		}
+		c.Next()
+	}
+}
 
-		tokenStr := parts[1]
-		claims, err := jwtManager.Verify(tokenStr)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+// MFAEnrollmentAuth accepts a regular access token or the enrollment token
+// handed out by login when the 2FA policy requires the user to enroll first.
+func MFAEnrollmentAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authenticate(c, auth.PurposeMFAEnrollment) {
+			return
+		}
+		if adminID, ok := impersonatorID(c); ok {
+			serveImpersonated(c, adminID)
 			return
 		}
+		c.Next()
+	}
+}
+
+// authenticate validates the bearer token and stores the caller in the
+// context. Purpose tokens are only accepted when they match allowedPurpose.
+func authenticate(c *gin.Context, allowedPurpose string) bool {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing Authorization header"})
+		return false
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid Authorization header"})
+		return false
+	}
+
+	tokenStr := parts[1]
+	claims, err := jwtManager.Verify(tokenStr)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+		return false
+	}
+
+	role, err := models.ParseRole(claims.Role)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+		return false
+	}
+
+	// suspended (or deleted) accounts lose access right away
+	var user models.User
+	if err := database.DB.Select("id", "suspended_at").First(&user, claims.UserID).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+		return false
+	}
+	if user.IsSuspended() {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "account suspended"})
+		return false
+	}
+
+	if claims.Purpose != "" {
+		if allowedPurpose == "" || claims.Purpose != allowedPurpose {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			return false
+		}
 
-		// store in context for handlers
 		c.Set("userID", claims.UserID)
-		c.Set("role", claims.Role)
+		c.Set("role", string(role))
+		c.Set("tokenPurpose", claims.Purpose)
+		return true
+	}
 
-		c.Next()
+	// the session must still be live (not logged out or revoked)
+	var session models.Session
+	if claims.SessionID == "" ||
+		database.DB.Where("id = ?", claims.SessionID).First(&session).Error != nil ||
+		session.RevokedAt != nil || session.UserID != claims.UserID {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session has been revoked"})
+		return false
 	}
+
+	// impersonation tokens must match their session, and the admin behind
+	// them must still be an active admin
+	if claims.ImpersonatorID != 0 || session.ImpersonatorID != nil {
+		if session.ImpersonatorID == nil || *session.ImpersonatorID != claims.ImpersonatorID ||
+			!isActiveAdmin(claims.ImpersonatorID) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session has been revoked"})
+			return false
+		}
+		c.Set("impersonatorID", claims.ImpersonatorID)
+	}
+
+	// enrollment must stay reachable for users caught by the policy
+	if config.Require2FAForPrivileged && role.IsPrivileged() && !session.MFA &&
+		allowedPurpose != auth.PurposeMFAEnrollment {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "two-factor authentication required for this account"})
+		return false
+	}
+
+	// store in context for handlers
+	c.Set("userID", claims.UserID)
+	c.Set("role", string(role))
+	c.Set("sessionID", claims.SessionID)
+	return true
 }
 
 // Only allow admin role
@@ -61,7 +152,7 @@ func AdminOnly() gin.HandlerFunc {
 		}
 
 		role, _ := roleVal.(string)
-		if role != "admin" {
+		if models.Role(role) != models.RoleAdmin {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin only"})
 			return
 		}
@@ -69,6 +160,7 @@ func AdminOnly() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
 func ShelterOnly() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		roleVal, exists := c.Get("role")
@@ -78,7 +170,7 @@ func ShelterOnly() gin.HandlerFunc {
 		}
 
 		role, _ := roleVal.(string)
-		if role != "shelter" && role != "admin" {
+		if models.Role(role) != models.RoleShelter && models.Role(role) != models.RoleAdmin {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "shelter or admin only"})
 			return
 		}
@@ -86,3 +178,28 @@ func ShelterOnly() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// VerifiedEmailOnly blocks users who haven't confirmed their email address.
+// Must run after AuthMiddleware.
+func VerifiedEmailOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDVal, exists := c.Get("userID")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user not found in context"})
+			return
+		}
+
+		var user models.User
+		if err := database.DB.Select("id", "email_verified_at").First(&user, userIDVal).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+			return
+		}
+
+		if user.EmailVerifiedAt == nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "please verify your email address first"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"pet-adoption-api/internal/database"
+	"pet-adoption-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// impersonatorID returns the admin behind an impersonation token, if any.
+func impersonatorID(c *gin.Context) (uint, bool) {
+	val, exists := c.Get("impersonatorID")
+	if !exists {
+		return 0, false
+	}
+	id, ok := val.(uint)
+	return id, ok
+}
+
+// isActiveAdmin reports whether the user is still an admin in good standing,
+// so revoking or suspending an admin also ends their impersonations.
+func isActiveAdmin(userID uint) bool {
+	var admin models.User
+	if err := database.DB.Select("id", "role", "suspended_at").First(&admin, userID).Error; err != nil {
+		return false
+	}
+	return admin.Role == models.RoleAdmin && !admin.IsSuspended()
+}
+
+// serveImpersonated runs the rest of the chain for a request made under
+// impersonation. Only reads are allowed; every request, allowed or not, is
+// written to the audit log against the admin.
+func serveImpersonated(c *gin.Context, adminID uint) {
+	userIDVal, _ := c.Get("userID")
+	userID, _ := userIDVal.(uint)
+	c.Header("X-Impersonated-By", strconv.FormatUint(uint64(adminID), 10))
+
+	action := models.AuditImpersonatedRequest
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		c.Next()
+	default:
+		action = models.AuditImpersonationBlocked
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "changes are not allowed while impersonating"})
+	}
+
+	entry := models.AuditLog{
+		ActorID:      adminID,
+		Action:       action,
+		TargetUserID: &userID,
+		Details: map[string]interface{}{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+			"status": c.Writer.Status(),
+		},
+	}
+	if err := database.DB.Create(&entry).Error; err != nil {
+		log.Printf("failed to audit impersonated request by admin %d: %v", adminID, err)
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// AdoptionStatusChange is one entry in an adoption request's status history.
+// Entries are append-only; the first one (FromStatus empty) is the
+// application itself. ActorID is nil for changes made by background jobs.
+type AdoptionStatusChange struct {
+	ID                uint           `gorm:"primaryKey" json:"id"`
+	AdoptionRequestID uint           `gorm:"not null;index" json:"adoption_request_id"`
+	ActorID           *uint          `json:"actor_id"`
+	FromStatus        AdoptionStatus `gorm:"type:varchar(20);not null;default:''" json:"from_status"`
+	ToStatus          AdoptionStatus `gorm:"type:varchar(20);not null" json:"to_status"`
+	Reason            string         `json:"reason,omitempty"`
+	CreatedAt         time.Time      `json:"created_at"`
+}
+
+// AdoptionNote is a private note shelter staff keep on an adoption request.
+// Applicants never see them.
+type AdoptionNote struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	AdoptionRequestID uint      `gorm:"not null;index" json:"adoption_request_id"`
+	AuthorID          uint      `gorm:"not null" json:"author_id"`
+	Body              string    `gorm:"not null" json:"body"`
+	CreatedAt         time.Time `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// AdoptionMessage is one message in the conversation between an applicant
+// and the shelter about an adoption request. FromShelter tells the two sides
+// apart, as any staff member may write for the shelter. ReadAt is set when
+// the other side first fetches the thread.
+type AdoptionMessage struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	AdoptionRequestID uint       `gorm:"not null;index" json:"adoption_request_id"`
+	SenderID          uint       `gorm:"not null;index" json:"sender_id"`
+	FromShelter       bool       `gorm:"not null" json:"from_shelter"`
+	Body              string     `gorm:"not null" json:"body"`
+	ReadAt            *time.Time `json:"read_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type AdoptionStatus string
 
@@ -12,15 +18,73 @@ const (
 	AdoptionStatusExpired   AdoptionStatus = "expired"
 )
 
+var ErrInvalidTransition = errors.New("invalid adoption status transition")
+
+// adoptionTransitions lists where each status may go next. Only pending
+// requests can change; every other status is final.
+var adoptionTransitions = map[AdoptionStatus][]AdoptionStatus{
+	AdoptionStatusPending: {
+		AdoptionStatusApproved,
+		AdoptionStatusRejected,
+		AdoptionStatusCancelled,
+		AdoptionStatusExpired,
+	},
+}
+
+// AllowedTransitions returns the statuses a request in status s may move to.
+func (s AdoptionStatus) AllowedTransitions() []AdoptionStatus {
+	return append([]AdoptionStatus{}, adoptionTransitions[s]...)
+}
+
+// CanTransitionTo reports whether the transition table allows s → to.
+func (s AdoptionStatus) CanTransitionTo(to AdoptionStatus) bool {
+	for _, next := range adoptionTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
 type AdoptionRequest struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
-	UserID    uint           `gorm:"not null" json:"user_id"`
-	PetID     uint           `gorm:"not null" json:"pet_id"`
+	UserID    uint           `gorm:"not null;uniqueIndex:idx_adoption_requests_pending_user_pet,where:status = 'pending'" json:"user_id"`
+	PetID     uint           `gorm:"not null;uniqueIndex:idx_adoption_requests_pending_user_pet" json:"pet_id"`
 	Status    AdoptionStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
 	Message   string         `json:"message"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
+	// the shelter questionnaire version the applicant answered, if any
+	QuestionnaireID *uint                  `json:"questionnaire_id,omitempty"`
+	Answers         map[string]interface{} `gorm:"serializer:json" json:"answers,omitempty"`
+	// why the request was rejected, cancelled or expired, for the applicant
+	StatusReason string `json:"status_reason,omitempty"`
+	// set once staff have been reminded that the request is about to expire
+	ReminderSentAt *time.Time `json:"-"`
+	// messages the viewer hasn't read yet; only filled in by list endpoints
+	UnreadMessages *int64 `gorm:"-" json:"unread_messages,omitempty"`
 
 	User User `gorm:"foreignKey:UserID" json:"-"`
 	Pet  Pet  `gorm:"foreignKey:PetID" json:"-"`
 }
+
+// MarshalJSON adds the transitions allowed from the current status, so
+// clients know which actions to offer.
+func (ar AdoptionRequest) MarshalJSON() ([]byte, error) {
+	type plain AdoptionRequest
+	return json.Marshal(struct {
+		plain
+		AllowedTransitions []AdoptionStatus `json:"allowed_transitions"`
+	}{plain(ar), ar.Status.AllowedTransitions()})
+}
+
+// AfterCreate starts the history of every new adoption request, however it
+// was created.
+func (ar *AdoptionRequest) AfterCreate(tx *gorm.DB) error {
+	return tx.Create(&AdoptionStatusChange{
+		AdoptionRequestID: ar.ID,
+		ActorID:           &ar.UserID,
+		ToStatus:          ar.Status,
+		CreatedAt:         ar.CreatedAt,
+	}).Error
+}
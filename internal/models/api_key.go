@@ -0,0 +1,67 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// APIKeyScope limits what an API key may do.
+type APIKeyScope string
+
+const (
+	ScopePetsWrite      APIKeyScope = "pets:write"
+	ScopeAdoptionsRead  APIKeyScope = "adoptions:read"
+	ScopeAdoptionsWrite APIKeyScope = "adoptions:write"
+)
+
+var ErrUnknownScope = errors.New("unknown scope")
+
+func ParseAPIKeyScope(s string) (APIKeyScope, error) {
+	switch sc := APIKeyScope(s); sc {
+	case ScopePetsWrite, ScopeAdoptionsRead, ScopeAdoptionsWrite:
+		return sc, nil
+	default:
+		return "", ErrUnknownScope
+	}
+}
+
+// ScopeFor maps a shelter permission to the API key scope that grants it.
+// Permissions without a scope can't be exercised with an API key.
+func ScopeFor(p ShelterPermission) (APIKeyScope, bool) {
+	switch p {
+	case PermManagePets:
+		return ScopePetsWrite, true
+	case PermViewAdoptions:
+		return ScopeAdoptionsRead, true
+	case PermReviewAdoptions:
+		return ScopeAdoptionsWrite, true
+	default:
+		return "", false
+	}
+}
+
+// APIKey is a long-lived credential for a shelter's integrations. Only the
+// hash is stored; Prefix identifies the key in listings.
+type APIKey struct {
+	ID          uint          `gorm:"primaryKey" json:"id"`
+	ShelterID   uint          `gorm:"not null;index" json:"shelter_id"`
+	Name        string        `gorm:"not null" json:"name"`
+	Prefix      string        `gorm:"type:varchar(16);not null" json:"prefix"`
+	KeyHash     string        `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	Scopes      []APIKeyScope `gorm:"serializer:json;not null" json:"scopes"`
+	CreatedByID uint          `gorm:"not null" json:"created_by_id"`
+	LastUsedAt  *time.Time    `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time    `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+
+	Shelter Shelter `gorm:"foreignKey:ShelterID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (k *APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
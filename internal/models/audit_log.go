@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditUserSuspended   AuditAction = "user.suspended"
+	AuditUserReactivated AuditAction = "user.reactivated"
+	AuditUserRoleChanged AuditAction = "user.role_changed"
+	AuditUserLoggedOut   AuditAction = "user.forced_logout"
+
+	AuditImpersonationStarted AuditAction = "impersonation.started"
+	AuditImpersonatedRequest  AuditAction = "impersonation.request"
+	AuditImpersonationBlocked AuditAction = "impersonation.blocked"
+)
+
+// AuditLog records an administrative action: who did what to which user.
+// Entries are append-only.
+type AuditLog struct {
+	ID           uint                   `gorm:"primaryKey" json:"id"`
+	ActorID      uint                   `gorm:"not null;index" json:"actor_id"`
+	Action       AuditAction            `gorm:"type:varchar(64);not null;index" json:"action"`
+	TargetUserID *uint                  `gorm:"index" json:"target_user_id,omitempty"`
+	Details      map[string]interface{} `gorm:"serializer:json" json:"details,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type ErasureRequestStatus string
+
+const (
+	ErasureRequestPending   ErasureRequestStatus = "pending"
+	ErasureRequestApproved  ErasureRequestStatus = "approved"
+	ErasureRequestRejected  ErasureRequestStatus = "rejected"
+	ErasureRequestCompleted ErasureRequestStatus = "completed"
+	ErasureRequestFailed    ErasureRequestStatus = "failed"
+)
+
+// ErasureRequest is a user's request to have their personal data erased.
+// An admin approves it; the erasure job then anonymizes the user. The
+// request itself is kept as the record that the erasure happened.
+type ErasureRequest struct {
+	ID           uint                 `gorm:"primaryKey" json:"id"`
+	UserID       uint                 `gorm:"not null;index" json:"user_id"`
+	Reason       string               `json:"reason"`
+	Status       ErasureRequestStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	ReviewNote   string               `json:"review_note,omitempty"`
+	ReviewedByID *uint                `json:"reviewed_by_id,omitempty"`
+	ReviewedAt   *time.Time           `json:"reviewed_at,omitempty"`
+	CompletedAt  *time.Time           `json:"completed_at,omitempty"`
+	FailureNote  string               `json:"failure_note,omitempty"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// LoginThrottle tracks failed logins for one key: either an account
+// ("account:<email>") or a client IP ("ip:<addr>").
+type LoginThrottle struct {
+	Key           string     `gorm:"primaryKey;type:varchar(320)" json:"key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	BlockedUntil  *time.Time `json:"blocked_until,omitempty"` // backoff, answered with 429
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`  // lockout, answered with 423
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// AccountThrottleKey is the throttle key for logins to the given email.
+func AccountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// PetHold reserves a pet for one applicant for a limited time, e.g. while a
+// home visit is arranged. The pet stays PetStatusReserved until the hold is
+// released or lapses; a pet has at most one active (unreleased) hold.
+type PetHold struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	PetID             uint       `gorm:"not null;uniqueIndex:idx_pet_holds_active_pet,where:released_at IS NULL" json:"pet_id"`
+	AdoptionRequestID uint       `gorm:"not null;index" json:"adoption_request_id"`
+	PlacedByID        uint       `gorm:"not null" json:"placed_by_id"`
+	Note              string     `json:"note,omitempty"`
+	ExpiresAt         time.Time  `gorm:"not null;index" json:"expires_at"`
+	ReleasedAt        *time.Time `json:"released_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type QuestionType string
+
+const (
+	QuestionText   QuestionType = "text"
+	QuestionChoice QuestionType = "choice"
+	QuestionYesNo  QuestionType = "yes_no"
+)
+
+// Question is one field of an adoption questionnaire. ID is the key the
+// applicant's answer is stored under.
+type Question struct {
+	ID       string       `json:"id"`
+	Label    string       `json:"label"`
+	Type     QuestionType `json:"type"`
+	Required bool         `json:"required"`
+	// the choices for QuestionChoice
+	Options []string `json:"options,omitempty"`
+}
+
+// Questionnaire is one version of the application form a shelter asks
+// adopters to fill in. Versions are never edited; publishing a new one
+// makes it the active (highest) version, and each adoption request keeps
+// the version it was answered against.
+type Questionnaire struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	ShelterID   uint       `gorm:"not null;uniqueIndex:idx_questionnaires_shelter_version" json:"shelter_id"`
+	Version     int        `gorm:"not null;uniqueIndex:idx_questionnaires_shelter_version" json:"version"`
+	Questions   []Question `gorm:"serializer:json;not null" json:"questions"`
+	CreatedByID uint       `gorm:"not null" json:"created_by_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ValidateQuestions checks a questionnaire definition before it is saved.
+func ValidateQuestions(questions []Question) error {
+	if len(questions) == 0 {
+		return errors.New("a questionnaire needs at least one question")
+	}
+
+	seen := make(map[string]bool, len(questions))
+	for i, q := range questions {
+		if strings.TrimSpace(q.ID) == "" || strings.TrimSpace(q.Label) == "" {
+			return fmt.Errorf("question %d needs an id and a label", i+1)
+		}
+		if seen[q.ID] {
+			return fmt.Errorf("duplicate question id %q", q.ID)
+		}
+		seen[q.ID] = true
+
+		switch q.Type {
+		case QuestionText, QuestionYesNo:
+			if len(q.Options) > 0 {
+				return fmt.Errorf("question %q: only choice questions have options", q.ID)
+			}
+		case QuestionChoice:
+			if len(q.Options) < 2 {
+				return fmt.Errorf("question %q: choice questions need at least two options", q.ID)
+			}
+		default:
+			return fmt.Errorf("question %q: unknown type %q", q.ID, q.Type)
+		}
+	}
+	return nil
+}
+
+// ValidateAnswers checks an applicant's answers against the questionnaire
+// and returns a message per offending question id (nil if all is well).
+func (qn Questionnaire) ValidateAnswers(answers map[string]interface{}) map[string]string {
+	problems := map[string]string{}
+
+	known := make(map[string]bool, len(qn.Questions))
+	for _, q := range qn.Questions {
+		known[q.ID] = true
+
+		answer, given := answers[q.ID]
+		if !given || answer == nil {
+			if q.Required {
+				problems[q.ID] = "required"
+			}
+			continue
+		}
+
+		switch q.Type {
+		case QuestionText:
+			s, ok := answer.(string)
+			if !ok {
+				problems[q.ID] = "must be text"
+			} else if q.Required && strings.TrimSpace(s) == "" {
+				problems[q.ID] = "required"
+			}
+		case QuestionYesNo:
+			if _, ok := answer.(bool); !ok {
+				problems[q.ID] = "must be true or false"
+			}
+		case QuestionChoice:
+			s, _ := answer.(string)
+			if !containsString(q.Options, s) {
+				problems[q.ID] = "must be one of: " + strings.Join(q.Options, ", ")
+			}
+		}
+	}
+
+	for id := range answers {
+		if !known[id] {
+			problems[id] = "unknown question"
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return problems
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
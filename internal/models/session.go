@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Session groups every refresh token issued from a single login (a token
+// family). Access tokens carry the session ID, so revoking the session
+// invalidates both the refresh tokens and any outstanding access tokens.
+type Session struct {
+	ID        string     `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	MFA       bool       `gorm:"not null;default:false" json:"mfa"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// set on impersonation sessions: the admin acting as UserID
+	ImpersonatorID *uint `gorm:"index" json:"impersonator_id,omitempty"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	SessionID string     `gorm:"type:varchar(64);not null;index" json:"session_id"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	Session Session `gorm:"foreignKey:SessionID" json:"-"`
+}
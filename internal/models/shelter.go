@@ -1,7 +1,17 @@
 package models
 
-import "time"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// MaxAdoptionRequestTTLDays bounds the adoption request TTL, per shelter and
+// server-wide.
+const MaxAdoptionRequestTTLDays = 365
+
+// Shelter.OwnerUserID is the founding owner. Permissions are resolved
+// through ShelterMember, which may list further owners and staff.
 type Shelter struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	Name        string    `gorm:"not null" json:"name"`
@@ -10,7 +20,22 @@ type Shelter struct {
 	OwnerUserID uint      `gorm:"not null" json:"owner_user_id"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	// pending adoption requests expire after this many days; 0 uses the server default
+	AdoptionRequestTTLDays int `gorm:"not null;default:0" json:"adoption_request_ttl_days"`
 
 	OwnerUser User  `gorm:"foreignKey:OwnerUserID" json:"-"`
 	Pets      []Pet `json:"pets,omitempty"`
 }
+
+// AfterCreate gives every new shelter an active owner membership for its
+// OwnerUserID, however the shelter was created.
+func (s *Shelter) AfterCreate(tx *gorm.DB) error {
+	now := time.Now()
+	return tx.Create(&ShelterMember{
+		ShelterID:  s.ID,
+		UserID:     s.OwnerUserID,
+		Role:       ShelterRoleOwner,
+		Status:     MembershipActive,
+		AcceptedAt: &now,
+	}).Error
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type ShelterApplicationStatus string
+
+const (
+	ShelterApplicationPending  ShelterApplicationStatus = "pending"
+	ShelterApplicationApproved ShelterApplicationStatus = "approved"
+	ShelterApplicationRejected ShelterApplicationStatus = "rejected"
+)
+
+// ShelterApplication is a user's request to become a shelter owner. An admin
+// reviews it; approval promotes the user to RoleShelter.
+type ShelterApplication struct {
+	ID           uint                     `gorm:"primaryKey" json:"id"`
+	UserID       uint                     `gorm:"not null;index" json:"user_id"`
+	ShelterName  string                   `gorm:"not null" json:"shelter_name"`
+	Address      string                   `json:"address"`
+	Phone        string                   `json:"phone"`
+	Message      string                   `json:"message"`
+	Status       ShelterApplicationStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	ReviewNote   string                   `json:"review_note,omitempty"`
+	ReviewedByID *uint                    `json:"reviewed_by_id,omitempty"`
+	ReviewedAt   *time.Time               `json:"reviewed_at,omitempty"`
+	ShelterID    *uint                    `json:"shelter_id,omitempty"`
+	CreatedAt    time.Time                `json:"created_at"`
+	UpdatedAt    time.Time                `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
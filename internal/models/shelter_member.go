@@ -0,0 +1,93 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ShelterRole is a user's role within one shelter (not to be confused with
+// the account-wide Role).
+type ShelterRole string
+
+const (
+	ShelterRoleOwner     ShelterRole = "owner"
+	ShelterRoleManager   ShelterRole = "manager"
+	ShelterRoleVolunteer ShelterRole = "volunteer"
+)
+
+var ErrUnknownShelterRole = errors.New("unknown shelter role")
+
+func ParseShelterRole(s string) (ShelterRole, error) {
+	switch r := ShelterRole(s); r {
+	case ShelterRoleOwner, ShelterRoleManager, ShelterRoleVolunteer:
+		return r, nil
+	default:
+		return "", ErrUnknownShelterRole
+	}
+}
+
+// ShelterPermission is something a member may do within their shelter.
+type ShelterPermission string
+
+const (
+	PermViewAdoptions   ShelterPermission = "adoptions:view"
+	PermReviewAdoptions ShelterPermission = "adoptions:review"
+	PermManagePets      ShelterPermission = "pets:manage"
+	PermManageMembers   ShelterPermission = "members:manage"
+	PermManageAPIKeys   ShelterPermission = "api_keys:manage"
+	// edit the adoption questionnaire
+	PermManageQuestionnaires ShelterPermission = "questionnaires:manage"
+	// change shelter settings such as the adoption request TTL
+	PermManageSettings ShelterPermission = "settings:manage"
+)
+
+var shelterRolePermissions = map[ShelterRole][]ShelterPermission{
+	ShelterRoleOwner:     {PermViewAdoptions, PermReviewAdoptions, PermManagePets, PermManageMembers, PermManageAPIKeys, PermManageQuestionnaires, PermManageSettings},
+	ShelterRoleManager:   {PermViewAdoptions, PermReviewAdoptions, PermManagePets, PermManageQuestionnaires, PermManageSettings},
+	ShelterRoleVolunteer: {PermViewAdoptions},
+}
+
+// Can reports whether the shelter role grants the permission.
+func (r ShelterRole) Can(p ShelterPermission) bool {
+	for _, granted := range shelterRolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// ShelterRolesWith lists the roles that grant a permission (for queries).
+func ShelterRolesWith(p ShelterPermission) []ShelterRole {
+	var roles []ShelterRole
+	for _, r := range []ShelterRole{ShelterRoleOwner, ShelterRoleManager, ShelterRoleVolunteer} {
+		if r.Can(p) {
+			roles = append(roles, r)
+		}
+	}
+	return roles
+}
+
+type MembershipStatus string
+
+const (
+	MembershipInvited MembershipStatus = "invited"
+	MembershipActive  MembershipStatus = "active"
+)
+
+// ShelterMember links a user to a shelter with a per-shelter role. Invites
+// only grant permissions once accepted.
+type ShelterMember struct {
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	ShelterID   uint             `gorm:"not null;uniqueIndex:idx_shelter_members_shelter_user" json:"shelter_id"`
+	UserID      uint             `gorm:"not null;uniqueIndex:idx_shelter_members_shelter_user;index" json:"user_id"`
+	Role        ShelterRole      `gorm:"type:varchar(20);not null" json:"role"`
+	Status      MembershipStatus `gorm:"type:varchar(20);not null;default:'invited'" json:"status"`
+	InvitedByID *uint            `json:"invited_by_id,omitempty"`
+	AcceptedAt  *time.Time       `json:"accepted_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+
+	Shelter Shelter `gorm:"foreignKey:ShelterID;constraint:OnDelete:CASCADE" json:"-"`
+	User    User    `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Role string
 
@@ -10,12 +15,52 @@ const (
 	RoleUser    Role = "user"
 )
 
+var ErrUnknownRole = errors.New("unknown role")
+
+// ParseRole converts a raw string (request body, token claim) into a Role,
+// rejecting anything that is not one of the known roles.
+func ParseRole(s string) (Role, error) {
+	switch r := Role(s); r {
+	case RoleAdmin, RoleShelter, RoleUser:
+		return r, nil
+	default:
+		return "", ErrUnknownRole
+	}
+}
+
+// IsPrivileged reports whether the role can manage shelters or adoptions
+// (and is therefore subject to the 2FA policy).
+func (r Role) IsPrivileged() bool {
+	return r == RoleAdmin || r == RoleShelter
+}
+
 type User struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	Name         string    `gorm:"not null" json:"name"`
-	Email        string    `gorm:"uniqueIndex;not null" json:"email"`
-	PasswordHash string    `gorm:"not null" json:"-"`
-	Role         Role      `gorm:"type:varchar(20);not null" json:"role"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	Name         string `gorm:"not null" json:"name"`
+	Email        string `gorm:"uniqueIndex;not null" json:"email"`
+	PasswordHash string `gorm:"not null" json:"-"`
+	Role         Role   `gorm:"type:varchar(20);not null" json:"role"`
+	// nil until the user follows the link in the verification email
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	// TOTP secret; set during enrollment, active once TOTPEnabledAt is set
+	TOTPSecret    string     `json:"-"`
+	TOTPEnabledAt *time.Time `json:"totp_enabled_at,omitempty"`
+	// last accepted time step, so a code can't be replayed
+	TOTPLastStep int64 `gorm:"not null;default:0" json:"-"`
+	// optional contact details
+	Phone   string `gorm:"type:varchar(32)" json:"phone,omitempty"`
+	Address string `json:"address,omitempty"`
+	// new address waiting for confirmation; Email changes once it's verified
+	PendingEmail *string `json:"pending_email,omitempty"`
+	// set by an admin; suspended users can't sign in or use existing tokens
+	SuspendedAt      *time.Time     `json:"suspended_at,omitempty"`
+	SuspensionReason string         `json:"suspension_reason,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// IsSuspended reports whether an admin has suspended the account.
+func (u User) IsSuspended() bool {
+	return u.SuspendedAt != nil
 }
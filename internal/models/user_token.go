@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type TokenPurpose string
+
+const (
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+	TokenPurposeEmailChange       TokenPurpose = "email_change"
+)
+
+// UserToken is a single-use, expiring token mailed to a user (password
+// reset, email verification and email change links). Only the hash of the
+// token is stored.
+type UserToken struct {
+	ID        uint         `gorm:"primaryKey" json:"id"`
+	UserID    uint         `gorm:"not null;index" json:"user_id"`
+	Purpose   TokenPurpose `gorm:"type:varchar(32);not null;index" json:"purpose"`
+	TokenHash string       `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time    `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time   `json:"used_at,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
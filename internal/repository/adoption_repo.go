@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"pet-adoption-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TransitionAdoption moves ar to status to, enforcing the transition table,
+// and appends the change to the request's history. actorID is nil for
+// background jobs. reason is shown to the applicant (e.g. why the request
+// was rejected) and may be empty. The update only applies if the stored
+// status is still ar.Status, so two concurrent changes can't both succeed;
+// the loser gets models.ErrInvalidTransition like any other illegal
+// transition.
+func TransitionAdoption(tx *gorm.DB, ar *models.AdoptionRequest, to models.AdoptionStatus, actorID *uint, reason string) error {
+	if !ar.Status.CanTransitionTo(to) {
+		return models.ErrInvalidTransition
+	}
+
+	now := time.Now()
+	res := tx.Model(&models.AdoptionRequest{}).
+		Where("id = ? AND status = ?", ar.ID, ar.Status).
+		Updates(map[string]interface{}{"status": to, "status_reason": reason, "updated_at": now})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return models.ErrInvalidTransition
+	}
+
+	if err := tx.Create(&models.AdoptionStatusChange{
+		AdoptionRequestID: ar.ID,
+		ActorID:           actorID,
+		FromStatus:        ar.Status,
+		ToStatus:          to,
+		Reason:            reason,
+		CreatedAt:         now,
+	}).Error; err != nil {
+		return err
+	}
+
+	ar.Status = to
+	ar.StatusReason = reason
+	ar.UpdatedAt = now
+	return nil
+}
+
+// ActiveHold returns the pet's unreleased hold, or nil if it has none.
+func ActiveHold(tx *gorm.DB, petID uint) (*models.PetHold, error) {
+	var holds []models.PetHold
+	if err := tx.Where("pet_id = ? AND released_at IS NULL", petID).Limit(1).Find(&holds).Error; err != nil {
+		return nil, err
+	}
+	if len(holds) == 0 {
+		return nil, nil
+	}
+	return &holds[0], nil
+}
+
+// ReleaseHold ends the active hold placed for an adoption request, if any,
+// and puts its pet back on offer. It reports whether this call released
+// it, so concurrent callers (such as the lapse job on several replicas)
+// know which one did.
+func ReleaseHold(tx *gorm.DB, requestID uint) (bool, error) {
+	var holds []models.PetHold
+	if err := tx.Where("adoption_request_id = ? AND released_at IS NULL", requestID).Find(&holds).Error; err != nil {
+		return false, err
+	}
+
+	released := false
+	for _, hold := range holds {
+		res := tx.Model(&models.PetHold{}).
+			Where("id = ? AND released_at IS NULL", hold.ID).
+			Update("released_at", time.Now())
+		if res.Error != nil {
+			return false, res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+		released = true
+
+		if err := tx.Model(&models.Pet{}).
+			Where("id = ? AND status = ?", hold.PetID, models.PetStatusReserved).
+			Updates(map[string]interface{}{"status": models.PetStatusAvailable, "updated_at": time.Now()}).Error; err != nil {
+			return false, err
+		}
+	}
+	return released, nil
+}
+
+// RejectCompetingAdoptions rejects every other pending request for the pet
+// of an approved request and returns them, with their applicants loaded, so
+// the caller can tell each one.
+func RejectCompetingAdoptions(tx *gorm.DB, approved models.AdoptionRequest, actorID *uint, reason string) ([]models.AdoptionRequest, error) {
+	var competing []models.AdoptionRequest
+	if err := tx.Preload("User").
+		Where("pet_id = ? AND id <> ? AND status = ?", approved.PetID, approved.ID, models.AdoptionStatusPending).
+		Find(&competing).Error; err != nil {
+		return nil, err
+	}
+
+	rejected := competing[:0]
+	for _, ar := range competing {
+		err := TransitionAdoption(tx, &ar, models.AdoptionStatusRejected, actorID, reason)
+		if errors.Is(err, models.ErrInvalidTransition) {
+			// withdrawn in the meantime
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		rejected = append(rejected, ar)
+	}
+	return rejected, nil
+}
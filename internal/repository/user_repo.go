@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"pet-adoption-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrSoleShelterOwner = errors.New("user is the only owner of a shelter")
+
+// SoleOwnedShelterIDs lists shelters where the user is the only active owner.
+func SoleOwnedShelterIDs(tx *gorm.DB, userID uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Model(&models.ShelterMember{}).
+		Where("user_id = ? AND role = ? AND status = ?", userID, models.ShelterRoleOwner, models.MembershipActive).
+		Where("NOT EXISTS (SELECT 1 FROM shelter_members other WHERE other.shelter_id = shelter_members.shelter_id "+
+			"AND other.user_id <> ? AND other.role = ? AND other.status = ?)",
+			userID, models.ShelterRoleOwner, models.MembershipActive).
+		Pluck("shelter_id", &ids).Error
+	return ids, err
+}
+
+// LeaveShelters ends all of the user's shelter memberships. Shelters whose
+// founding owner is the user pass to one of their remaining owners. Fails
+// with ErrSoleShelterOwner if a shelter would be left without an owner.
+func LeaveShelters(tx *gorm.DB, userID uint) error {
+	sole, err := SoleOwnedShelterIDs(tx, userID)
+	if err != nil {
+		return err
+	}
+	if len(sole) > 0 {
+		return ErrSoleShelterOwner
+	}
+
+	if err := tx.Exec("UPDATE shelters SET owner_user_id = (SELECT m.user_id FROM shelter_members m "+
+		"WHERE m.shelter_id = shelters.id AND m.user_id <> ? AND m.role = ? AND m.status = ? ORDER BY m.id LIMIT 1) "+
+		"WHERE owner_user_id = ?",
+		userID, models.ShelterRoleOwner, models.MembershipActive, userID).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&models.ShelterMember{}).Error
+}
+
+// CancelPendingAdoptions cancels the user's pending adoption requests,
+// releasing any pet held for them, and returns them (with the new status)
+// so callers can publish events.
+func CancelPendingAdoptions(tx *gorm.DB, userID uint) ([]models.AdoptionRequest, error) {
+	var requests []models.AdoptionRequest
+	if err := tx.Where("user_id = ? AND status = ?", userID, models.AdoptionStatusPending).
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+
+	cancelled := requests[:0]
+	for _, ar := range requests {
+		err := TransitionAdoption(tx, &ar, models.AdoptionStatusCancelled, &userID, "The applicant's account was closed.")
+		if errors.Is(err, models.ErrInvalidTransition) {
+			// decided in the meantime
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := ReleaseHold(tx, ar.ID); err != nil {
+			return nil, err
+		}
+		cancelled = append(cancelled, ar)
+	}
+	return cancelled, nil
+}
+
+// CloseUserAccount removes every way of signing in as the user, including
+// the API keys they created, and soft-deletes them. The email is replaced so
+// the address can be registered again, and contact details are cleared.
+// Works on already closed accounts.
+func CloseUserAccount(tx *gorm.DB, userID uint) error {
+	var user models.User
+	if err := tx.Unscoped().First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := tx.Model(&models.ShelterApplication{}).
+		Where("user_id = ? AND status = ?", userID, models.ShelterApplicationPending).
+		Updates(map[string]interface{}{
+			"status":      models.ShelterApplicationRejected,
+			"review_note": "account closed",
+			"reviewed_at": now,
+		}).Error; err != nil {
+		return err
+	}
+
+	for _, m := range []interface{}{&models.UserIdentity{}, &models.UserToken{}, &models.RecoveryCode{}} {
+		if err := tx.Where("user_id = ?", userID).Delete(m).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("key = ?", models.AccountThrottleKey(user.Email)).Delete(&models.LoginThrottle{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.APIKey{}).
+		Where("created_by_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Unscoped().Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"email":           fmt.Sprintf("deleted-%d@deleted.invalid", userID),
+		"pending_email":   nil,
+		"phone":           "",
+		"address":         "",
+		"totp_secret":     "",
+		"totp_enabled_at": nil,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&models.User{}, userID).Error
+}
+
+// AnonymizeUser erases the user's personal data for good: the account is
+// closed, the name and password are wiped, and free text, questionnaire
+// answers and messages they wrote are blanked. Adoption requests keep their
+// pet, status and dates so shelter statistics stay intact; pending ones are
+// cancelled and returned.
+func AnonymizeUser(tx *gorm.DB, userID uint) ([]models.AdoptionRequest, error) {
+	if err := LeaveShelters(tx, userID); err != nil {
+		return nil, err
+	}
+
+	cancelled, err := CancelPendingAdoptions(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&models.AdoptionRequest{}).Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"message": "", "answers": nil}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&models.AdoptionMessage{}).Where("sender_id = ?", userID).
+		Update("body", "").Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&models.ShelterApplication{}).Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"address": "", "phone": "", "message": ""}).Error; err != nil {
+		return nil, err
+	}
+
+	if err := CloseUserAccount(tx, userID); err != nil {
+		return nil, err
+	}
+
+	// an empty hash never matches a password
+	err = tx.Unscoped().Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"name":              "Deleted user",
+		"password_hash":     "",
+		"email_verified_at": nil,
+	}).Error
+	return cancelled, err
+}
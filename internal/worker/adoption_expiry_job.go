@@ -0,0 +1,228 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"pet-adoption-api/internal/models"
+	"pet-adoption-api/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const adoptionExpiryJobInterval = 10 * time.Minute
+
+// AdoptionExpiryJob expires pending adoption requests once they are older
+// than their shelter's TTL, after reminding the shelter's reviewers shortly
+// before. Reminders and expiries are both claimed with conditional updates,
+// so several API replicas can run the job side by side.
+type AdoptionExpiryJob struct {
+	DB            *gorm.DB
+	Events        chan<- AdoptionEvent // optional; gets the expired requests
+	Notifications chan<- Notification  // optional; reminders to staff
+	// DefaultTTL applies to shelters without their own TTL
+	DefaultTTL time.Duration
+	// ReminderLead is capped at half the TTL, so short TTLs don't remind
+	// the moment a request arrives
+	ReminderLead time.Duration
+	// NotifyTimeout is how long a reminder waits for room in the
+	// notification queue before it is put back for the next run
+	NotifyTimeout time.Duration
+}
+
+func NewAdoptionExpiryJob(db *gorm.DB, events chan<- AdoptionEvent, notifications chan<- Notification) *AdoptionExpiryJob {
+	return &AdoptionExpiryJob{
+		DB:            db,
+		Events:        events,
+		Notifications: notifications,
+		DefaultTTL:    30 * 24 * time.Hour,
+		ReminderLead:  3 * 24 * time.Hour,
+		NotifyTimeout: 5 * time.Second,
+	}
+}
+
+// Start runs the job every ten minutes until ctx is cancelled.
+func (j *AdoptionExpiryJob) Start(ctx context.Context) {
+	log.Println("[WORKER] Adoption expiry job started")
+
+	ticker := time.NewTicker(adoptionExpiryJobInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.RunOnce(ctx); err != nil {
+			log.Printf("[WORKER] Adoption expiry job failed: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("[WORKER] Adoption expiry job shutting down...")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce sends due reminders and expires overdue requests in every shelter.
+func (j *AdoptionExpiryJob) RunOnce(ctx context.Context) error {
+	var shelters []models.Shelter
+	if err := j.DB.WithContext(ctx).Select("id", "name", "adoption_request_ttl_days").
+		Find(&shelters).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, shelter := range shelters {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		ttl := j.DefaultTTL
+		if shelter.AdoptionRequestTTLDays > 0 {
+			// clamped so a bad value can't overflow into a tiny TTL
+			days := min(shelter.AdoptionRequestTTLDays, models.MaxAdoptionRequestTTLDays)
+			ttl = time.Duration(days) * 24 * time.Hour
+		}
+		if ttl <= 0 {
+			continue
+		}
+
+		if err := j.expire(ctx, shelter, now.Add(-ttl), ttl); err != nil {
+			return err
+		}
+		lead := min(j.ReminderLead, ttl/2)
+		if err := j.remind(ctx, shelter, now.Add(-ttl+lead), ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pendingRequests finds the shelter's pending requests created before cutoff.
+func (j *AdoptionExpiryJob) pendingRequests(ctx context.Context, shelterID uint, cutoff time.Time) *gorm.DB {
+	return j.DB.WithContext(ctx).
+		Where("status = ? AND created_at < ?", models.AdoptionStatusPending, cutoff).
+		Where("pet_id IN (?)", j.DB.Model(&models.Pet{}).Select("id").Where("shelter_id = ?", shelterID))
+}
+
+func (j *AdoptionExpiryJob) expire(ctx context.Context, shelter models.Shelter, cutoff time.Time, ttl time.Duration) error {
+	reason := fmt.Sprintf("The shelter did not decide within %d days.", int(ttl.Hours()/24))
+
+	var requests []models.AdoptionRequest
+	if err := j.pendingRequests(ctx, shelter.ID, cutoff).Find(&requests).Error; err != nil {
+		return err
+	}
+
+	for _, ar := range requests {
+		err := j.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := repository.TransitionAdoption(tx, &ar, models.AdoptionStatusExpired, nil, reason); err != nil {
+				return err
+			}
+			_, err := repository.ReleaseHold(tx, ar.ID)
+			return err
+		})
+		if errors.Is(err, models.ErrInvalidTransition) {
+			// decided, withdrawn or expired by another replica meanwhile
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		log.Printf("[WORKER] Adoption request %d expired\n", ar.ID)
+		j.publish(AdoptionEvent{
+			RequestID: ar.ID,
+			UserID:    ar.UserID,
+			PetID:     ar.PetID,
+			Status:    string(ar.Status),
+			Message:   "Adoption request expired without a decision",
+		})
+	}
+	return nil
+}
+
+func (j *AdoptionExpiryJob) remind(ctx context.Context, shelter models.Shelter, cutoff time.Time, ttl time.Duration) error {
+	var requests []models.AdoptionRequest
+	if err := j.pendingRequests(ctx, shelter.ID, cutoff).
+		Where("reminder_sent_at IS NULL").
+		Preload("Pet").
+		Find(&requests).Error; err != nil {
+		return err
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+
+	var reviewers []string
+	if err := j.DB.WithContext(ctx).Model(&models.User{}).
+		Joins("JOIN shelter_members ON shelter_members.user_id = users.id").
+		Where("shelter_members.shelter_id = ? AND shelter_members.status = ? AND shelter_members.role IN ?",
+			shelter.ID, models.MembershipActive, models.ShelterRolesWith(models.PermReviewAdoptions)).
+		Pluck("users.email", &reviewers).Error; err != nil {
+		return err
+	}
+
+	for _, ar := range requests {
+		res := j.DB.WithContext(ctx).Model(&models.AdoptionRequest{}).
+			Where("id = ? AND status = ? AND reminder_sent_at IS NULL", ar.ID, models.AdoptionStatusPending).
+			Update("reminder_sent_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+
+		expiresAt := ar.CreatedAt.Add(ttl)
+		for _, to := range reviewers {
+			sent := j.notify(ctx, Notification{
+				To:      to,
+				Subject: "Adoption request for " + ar.Pet.Name + " is about to expire",
+				Body: fmt.Sprintf("Adoption request #%d for %s at %s is still pending and will expire on %s "+
+					"unless it is approved or rejected.", ar.ID, ar.Pet.Name, shelter.Name, expiresAt.Format("2 Jan 2006 15:04 MST")),
+			})
+			if !sent {
+				// give the claim back so the next run retries; reviewers
+				// already mailed may get the reminder twice, which beats
+				// nobody getting it
+				return j.DB.WithContext(context.WithoutCancel(ctx)).Model(&models.AdoptionRequest{}).
+					Where("id = ?", ar.ID).
+					Update("reminder_sent_at", nil).Error
+			}
+		}
+	}
+	return nil
+}
+
+func (j *AdoptionExpiryJob) publish(evt AdoptionEvent) {
+	if j.Events == nil {
+		return
+	}
+	select {
+	case j.Events <- evt:
+	default:
+		// channel full → skip
+	}
+}
+
+// notify queues n, waiting up to NotifyTimeout for room, and reports
+// whether it was queued.
+func (j *AdoptionExpiryJob) notify(ctx context.Context, n Notification) bool {
+	if j.Notifications == nil {
+		return true
+	}
+
+	timer := time.NewTimer(j.NotifyTimeout)
+	defer timer.Stop()
+	select {
+	case j.Notifications <- n:
+		return true
+	case <-timer.C:
+		log.Printf("[WORKER] notification queue full, will retry %q for %s\n", n.Subject, n.To)
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
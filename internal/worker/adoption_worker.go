@@ -16,12 +16,16 @@ type AdoptionEvent struct {
 }
 
 type AdoptionWorker struct {
-	Events chan AdoptionEvent
+	Events        chan AdoptionEvent
+	Notifications chan Notification
+	Notifier      Notifier
 }
 
 func NewAdoptionWorker(buffer int) *AdoptionWorker {
 	return &AdoptionWorker{
-		Events: make(chan AdoptionEvent, buffer),
+		Events:        make(chan AdoptionEvent, buffer),
+		Notifications: make(chan Notification, buffer),
+		Notifier:      LogNotifier{},
 	}
 }
 
@@ -44,6 +48,11 @@ func (w *AdoptionWorker) Start(ctx context.Context) {
 
 			// simulate slow notification
 			time.Sleep(1 * time.Second)
+
+		case n := <-w.Notifications:
+			if err := w.Notifier.Send(n); err != nil {
+				log.Printf("[WORKER] Failed to send notification to %s: %v\n", n.To, err)
+			}
 		}
 	}
 }
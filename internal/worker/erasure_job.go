@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"pet-adoption-api/internal/models"
+	"pet-adoption-api/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const erasureJobInterval = time.Minute
+
+var errAlreadyClaimed = errors.New("erasure request already claimed")
+
+// ErasureJob carries out approved erasure requests. Each request is claimed
+// with a conditional update inside the erasure transaction, so several API
+// replicas can run the job side by side without erasing twice.
+type ErasureJob struct {
+	DB     *gorm.DB
+	Events chan<- AdoptionEvent // optional; gets the cancelled adoption requests
+}
+
+func NewErasureJob(db *gorm.DB, events chan<- AdoptionEvent) *ErasureJob {
+	return &ErasureJob{DB: db, Events: events}
+}
+
+// Start runs the job every minute until ctx is cancelled.
+func (j *ErasureJob) Start(ctx context.Context) {
+	log.Println("[WORKER] Erasure job started")
+
+	ticker := time.NewTicker(erasureJobInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.RunOnce(ctx); err != nil {
+			log.Printf("[WORKER] Erasure job failed: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("[WORKER] Erasure job shutting down...")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce processes every approved erasure request.
+func (j *ErasureJob) RunOnce(ctx context.Context) error {
+	var requests []models.ErasureRequest
+	if err := j.DB.WithContext(ctx).
+		Where("status = ?", models.ErasureRequestApproved).
+		Order("reviewed_at").
+		Find(&requests).Error; err != nil {
+		return err
+	}
+
+	for _, er := range requests {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		j.erase(ctx, er)
+	}
+	return nil
+}
+
+func (j *ErasureJob) erase(ctx context.Context, er models.ErasureRequest) {
+	var cancelled []models.AdoptionRequest
+	err := j.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.ErasureRequest{}).
+			Where("id = ? AND status = ?", er.ID, models.ErasureRequestApproved).
+			Updates(map[string]interface{}{"status": models.ErasureRequestCompleted, "completed_at": time.Now()})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errAlreadyClaimed
+		}
+
+		var err error
+		cancelled, err = repository.AnonymizeUser(tx, er.UserID)
+		return err
+	})
+	if errors.Is(err, errAlreadyClaimed) {
+		return
+	}
+	if err != nil {
+		log.Printf("[WORKER] Erasure of user %d failed: %v\n", er.UserID, err)
+		j.DB.Model(&models.ErasureRequest{}).
+			Where("id = ? AND status = ?", er.ID, models.ErasureRequestApproved).
+			Updates(map[string]interface{}{"status": models.ErasureRequestFailed, "failure_note": err.Error()})
+		return
+	}
+
+	log.Printf("[WORKER] Erased personal data of user %d (request %d)\n", er.UserID, er.ID)
+	for _, ar := range cancelled {
+		j.publish(AdoptionEvent{
+			RequestID: ar.ID,
+			UserID:    ar.UserID,
+			PetID:     ar.PetID,
+			Status:    string(ar.Status),
+			Message:   "Adoption request cancelled: applicant's data was erased",
+		})
+	}
+}
+
+func (j *ErasureJob) publish(evt AdoptionEvent) {
+	if j.Events == nil {
+		return
+	}
+	select {
+	case j.Events <- evt:
+	default:
+		// channel full → skip
+	}
+}
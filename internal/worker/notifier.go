@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Notification is a message addressed to a user (an email in production).
+type Notification struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers notifications. The worker owns one and calls it from its
+// goroutine, so implementations don't need to be safe for concurrent use.
+type Notifier interface {
+	Send(n Notification) error
+}
+
+// LogNotifier just logs notifications. Bodies carry reset and verification
+// links, so they are only logged when ShowBody is set (dev).
+type LogNotifier struct {
+	ShowBody bool
+}
+
+func (l LogNotifier) Send(n Notification) error {
+	if !l.ShowBody {
+		log.Printf("[WORKER] Notification → to=%s subject=%q (body not logged)", n.To, n.Subject)
+		return nil
+	}
+	log.Printf("[WORKER] Notification → to=%s subject=%q\n%s\n", n.To, n.Subject, n.Body)
+	return nil
+}
+
+// MailSinkNotifier writes every notification to its own file in Dir, acting
+// as a local mailbox during development.
+type MailSinkNotifier struct {
+	Dir string
+}
+
+func (s MailSinkNotifier) Send(n Notification) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), sanitizeFileName(n.To))
+	content := fmt.Sprintf("To: %s\nSubject: %s\nDate: %s\n\n%s\n",
+		n.To, n.Subject, time.Now().Format(time.RFC1123Z), n.Body)
+
+	return os.WriteFile(filepath.Join(s.Dir, name), []byte(content), 0o644)
+}
+
+// NotifierFromEnv returns a MailSinkNotifier when MAIL_SINK_DIR is set and a
+// LogNotifier otherwise, which logs bodies only in dev.
+func NotifierFromEnv(dev bool) Notifier {
+	if dir := os.Getenv("MAIL_SINK_DIR"); dir != "" {
+		return MailSinkNotifier{Dir: dir}
+	}
+	return LogNotifier{ShowBody: dev}
+}
+
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
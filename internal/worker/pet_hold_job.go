@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pet-adoption-api/internal/models"
+	"pet-adoption-api/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const petHoldJobInterval = time.Minute
+
+// PetHoldJob lets expired pet holds lapse, putting the pets back on offer.
+// repository.ReleaseHold only releases a hold once, so several API replicas
+// can run the job side by side.
+type PetHoldJob struct {
+	DB     *gorm.DB
+	Events chan<- AdoptionEvent // optional; gets the requests whose hold lapsed
+}
+
+func NewPetHoldJob(db *gorm.DB, events chan<- AdoptionEvent) *PetHoldJob {
+	return &PetHoldJob{DB: db, Events: events}
+}
+
+// Start runs the job every minute until ctx is cancelled.
+func (j *PetHoldJob) Start(ctx context.Context) {
+	log.Println("[WORKER] Pet hold job started")
+
+	ticker := time.NewTicker(petHoldJobInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.RunOnce(ctx); err != nil {
+			log.Printf("[WORKER] Pet hold job failed: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("[WORKER] Pet hold job shutting down...")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce releases every hold that has expired.
+func (j *PetHoldJob) RunOnce(ctx context.Context) error {
+	var holds []models.PetHold
+	if err := j.DB.WithContext(ctx).
+		Where("released_at IS NULL AND expires_at < ?", time.Now()).
+		Find(&holds).Error; err != nil {
+		return err
+	}
+
+	for _, hold := range holds {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var released bool
+		err := j.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var err error
+			released, err = repository.ReleaseHold(tx, hold.AdoptionRequestID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if !released {
+			continue
+		}
+
+		log.Printf("[WORKER] Hold %d on pet %d lapsed\n", hold.ID, hold.PetID)
+
+		var ar models.AdoptionRequest
+		if err := j.DB.WithContext(ctx).First(&ar, hold.AdoptionRequestID).Error; err != nil {
+			continue
+		}
+		j.publish(AdoptionEvent{
+			RequestID: ar.ID,
+			UserID:    ar.UserID,
+			PetID:     ar.PetID,
+			Status:    string(ar.Status),
+			Message:   "Hold on the pet lapsed; the pet is available again",
+		})
+	}
+	return nil
+}
+
+func (j *PetHoldJob) publish(evt AdoptionEvent) {
+	if j.Events == nil {
+		return
+	}
+	select {
+	case j.Events <- evt:
+	default:
+		// channel full → skip
+	}
+}
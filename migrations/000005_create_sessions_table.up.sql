@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS sessions (
+                                        id VARCHAR(64) PRIMARY KEY,
+                                        user_id INT NOT NULL,
+                                        revoked_at TIMESTAMPTZ,
+                                        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_sessions_user
+    FOREIGN KEY (user_id)
+    REFERENCES users (id)
+    ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+                                              id SERIAL PRIMARY KEY,
+                                              session_id VARCHAR(64) NOT NULL,
+                                              token_hash VARCHAR(64) NOT NULL UNIQUE,
+                                              expires_at TIMESTAMPTZ NOT NULL,
+                                              used_at TIMESTAMPTZ,
+                                              created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_refresh_tokens_session
+    FOREIGN KEY (session_id)
+    REFERENCES sessions (id)
+    ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens (session_id);
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS shelter_applications (
+                                                    id SERIAL PRIMARY KEY,
+                                                    user_id INT NOT NULL,
+                                                    shelter_name TEXT NOT NULL,
+                                                    address TEXT,
+                                                    phone TEXT,
+                                                    message TEXT,
+                                                    status TEXT NOT NULL DEFAULT 'pending'
+                                                    CHECK (status IN ('pending', 'approved', 'rejected')),
+    review_note TEXT,
+    reviewed_by_id INT,
+    reviewed_at TIMESTAMPTZ,
+    shelter_id INT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_shelter_applications_user
+    FOREIGN KEY (user_id)
+    REFERENCES users (id)
+    ON DELETE CASCADE,
+
+    CONSTRAINT fk_shelter_applications_reviewer
+    FOREIGN KEY (reviewed_by_id)
+    REFERENCES users (id)
+    ON DELETE SET NULL,
+
+    CONSTRAINT fk_shelter_applications_shelter
+    FOREIGN KEY (shelter_id)
+    REFERENCES shelters (id)
+    ON DELETE SET NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_shelter_applications_user_id ON shelter_applications (user_id);
+CREATE INDEX IF NOT EXISTS idx_shelter_applications_status ON shelter_applications (status);
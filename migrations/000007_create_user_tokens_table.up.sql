@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS user_tokens (
+                                           id SERIAL PRIMARY KEY,
+                                           user_id INT NOT NULL,
+                                           purpose VARCHAR(32) NOT NULL,
+                                           token_hash VARCHAR(64) NOT NULL UNIQUE,
+                                           expires_at TIMESTAMPTZ NOT NULL,
+                                           used_at TIMESTAMPTZ,
+                                           created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_user_tokens_user
+    FOREIGN KEY (user_id)
+    REFERENCES users (id)
+    ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON user_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_tokens_purpose ON user_tokens (purpose);
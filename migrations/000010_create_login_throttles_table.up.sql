@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS login_throttles (
+                                               key VARCHAR(320) PRIMARY KEY,
+                                               failures INT NOT NULL DEFAULT 0,
+                                               last_failure_at TIMESTAMPTZ,
+                                               blocked_until TIMESTAMPTZ,
+                                               locked_until TIMESTAMPTZ,
+                                               updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
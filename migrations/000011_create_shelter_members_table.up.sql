@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS shelter_members (
+                                               id SERIAL PRIMARY KEY,
+                                               shelter_id INT NOT NULL,
+                                               user_id INT NOT NULL,
+                                               role TEXT NOT NULL CHECK (role IN ('owner', 'manager', 'volunteer')),
+                                               status TEXT NOT NULL DEFAULT 'invited'
+                                               CHECK (status IN ('invited', 'active')),
+    invited_by_id INT,
+    accepted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_shelter_members_shelter
+    FOREIGN KEY (shelter_id)
+    REFERENCES shelters (id)
+    ON DELETE CASCADE,
+
+    CONSTRAINT fk_shelter_members_user
+    FOREIGN KEY (user_id)
+    REFERENCES users (id)
+    ON DELETE CASCADE,
+
+    CONSTRAINT fk_shelter_members_invited_by
+    FOREIGN KEY (invited_by_id)
+    REFERENCES users (id)
+    ON DELETE SET NULL
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shelter_members_shelter_user ON shelter_members (shelter_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_shelter_members_user_id ON shelter_members (user_id);
+
+-- every existing shelter owner becomes an active owner member
+INSERT INTO shelter_members (shelter_id, user_id, role, status, accepted_at, created_at, updated_at)
+SELECT id, owner_user_id, 'owner', 'active', created_at, created_at, created_at
+FROM shelters
+    ON CONFLICT (shelter_id, user_id) DO NOTHING;
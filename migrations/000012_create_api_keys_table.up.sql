@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+                                        id SERIAL PRIMARY KEY,
+                                        shelter_id INT NOT NULL,
+                                        name TEXT NOT NULL,
+                                        prefix VARCHAR(16) NOT NULL,
+                                        key_hash VARCHAR(64) NOT NULL UNIQUE,
+                                        scopes TEXT NOT NULL,          -- JSON array, e.g. ["pets:write"]
+                                        created_by_id INT NOT NULL,
+                                        last_used_at TIMESTAMPTZ,
+                                        revoked_at TIMESTAMPTZ,
+                                        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_api_keys_shelter
+    FOREIGN KEY (shelter_id)
+    REFERENCES shelters (id)
+    ON DELETE CASCADE,
+
+    CONSTRAINT fk_api_keys_created_by
+    FOREIGN KEY (created_by_id)
+    REFERENCES users (id)
+    ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_shelter_id ON api_keys (shelter_id);
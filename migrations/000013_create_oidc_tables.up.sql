@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS user_identities (
+                                               id SERIAL PRIMARY KEY,
+                                               user_id INT NOT NULL,
+                                               issuer TEXT NOT NULL,
+                                               subject TEXT NOT NULL,
+                                               email TEXT NOT NULL,
+                                               created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_user_identities_user
+    FOREIGN KEY (user_id)
+    REFERENCES users (id)
+    ON DELETE CASCADE
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_issuer_subject ON user_identities (issuer, subject);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
+
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+                                                 state_hash VARCHAR(64) PRIMARY KEY,
+                                                 nonce TEXT NOT NULL,
+                                                 code_verifier TEXT NOT NULL,
+                                                 expires_at TIMESTAMPTZ NOT NULL,
+                                                 created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_oidc_login_states_expires_at ON oidc_login_states (expires_at);
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS erasure_requests (
+                                                id SERIAL PRIMARY KEY,
+                                                user_id INT NOT NULL,
+                                                reason TEXT,
+                                                status VARCHAR(20) NOT NULL DEFAULT 'pending'
+                                                CHECK (status IN ('pending', 'approved', 'rejected', 'completed', 'failed')),
+    review_note TEXT,
+    reviewed_by_id INT,
+    reviewed_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    failure_note TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_erasure_requests_user
+    FOREIGN KEY (user_id)
+    REFERENCES users (id)
+    ON DELETE CASCADE,
+
+    CONSTRAINT fk_erasure_requests_reviewed_by
+    FOREIGN KEY (reviewed_by_id)
+    REFERENCES users (id)
+    ON DELETE SET NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_erasure_requests_user_id ON erasure_requests (user_id);
+CREATE INDEX IF NOT EXISTS idx_erasure_requests_status ON erasure_requests (status);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sessions_impersonator_id;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS impersonator_id;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_adoption_requests_status_created_at;
+
+ALTER TABLE adoption_requests DROP COLUMN IF EXISTS reminder_sent_at;
+
+ALTER TABLE shelters DROP COLUMN IF EXISTS adoption_request_ttl_days;
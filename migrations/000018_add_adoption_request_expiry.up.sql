@@ -0,0 +1,5 @@
+ALTER TABLE shelters ADD COLUMN IF NOT EXISTS adoption_request_ttl_days INT NOT NULL DEFAULT 0;
+
+ALTER TABLE adoption_requests ADD COLUMN IF NOT EXISTS reminder_sent_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_adoption_requests_status_created_at ON adoption_requests (status, created_at);
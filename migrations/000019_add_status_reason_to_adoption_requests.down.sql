@@ -0,0 +1 @@
+ALTER TABLE adoption_requests DROP COLUMN IF EXISTS status_reason;
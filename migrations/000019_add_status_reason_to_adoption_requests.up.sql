@@ -0,0 +1 @@
+ALTER TABLE adoption_requests ADD COLUMN IF NOT EXISTS status_reason TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_adoption_requests_pending_user_pet;
@@ -0,0 +1,17 @@
+-- keep only the oldest pending request per applicant and pet
+UPDATE adoption_requests ar
+SET status = 'cancelled',
+    status_reason = 'Duplicate request',
+    updated_at = NOW()
+WHERE ar.status = 'pending'
+  AND EXISTS (
+    SELECT 1 FROM adoption_requests older
+    WHERE older.user_id = ar.user_id
+      AND older.pet_id = ar.pet_id
+      AND older.status = 'pending'
+      AND older.id < ar.id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_adoption_requests_pending_user_pet
+    ON adoption_requests (user_id, pet_id)
+    WHERE status = 'pending';
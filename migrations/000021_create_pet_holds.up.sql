@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS pet_holds (
+                                         id SERIAL PRIMARY KEY,
+                                         pet_id INT NOT NULL REFERENCES pets (id) ON DELETE CASCADE,
+                                         adoption_request_id INT NOT NULL REFERENCES adoption_requests (id) ON DELETE CASCADE,
+                                         placed_by_id INT NOT NULL REFERENCES users (id),
+                                         note TEXT,
+                                         expires_at TIMESTAMPTZ NOT NULL,
+                                         released_at TIMESTAMPTZ,
+                                         created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+-- a pet has at most one active hold
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pet_holds_active_pet ON pet_holds (pet_id) WHERE released_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_pet_holds_adoption_request_id ON pet_holds (adoption_request_id);
+CREATE INDEX IF NOT EXISTS idx_pet_holds_expires_at ON pet_holds (expires_at);
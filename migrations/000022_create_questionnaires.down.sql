@@ -0,0 +1,4 @@
+ALTER TABLE adoption_requests DROP COLUMN IF EXISTS answers;
+ALTER TABLE adoption_requests DROP COLUMN IF EXISTS questionnaire_id;
+
+DROP TABLE IF EXISTS questionnaires;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS questionnaires (
+                                              id SERIAL PRIMARY KEY,
+                                              shelter_id INT NOT NULL REFERENCES shelters (id) ON DELETE CASCADE,
+                                              version INT NOT NULL,
+                                              questions TEXT NOT NULL,         -- JSON array of questions
+                                              created_by_id INT NOT NULL REFERENCES users (id),
+                                              created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_questionnaires_shelter_version ON questionnaires (shelter_id, version);
+
+ALTER TABLE adoption_requests ADD COLUMN IF NOT EXISTS questionnaire_id INT REFERENCES questionnaires (id);
+ALTER TABLE adoption_requests ADD COLUMN IF NOT EXISTS answers TEXT;   -- JSON object keyed by question id
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS adoption_messages (
+                                                 id SERIAL PRIMARY KEY,
+                                                 adoption_request_id INT NOT NULL REFERENCES adoption_requests (id) ON DELETE CASCADE,
+                                                 sender_id INT NOT NULL REFERENCES users (id),
+                                                 from_shelter BOOLEAN NOT NULL,
+                                                 body TEXT NOT NULL,
+                                                 read_at TIMESTAMPTZ,
+                                                 created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_adoption_messages_adoption_request_id ON adoption_messages (adoption_request_id);
+CREATE INDEX IF NOT EXISTS idx_adoption_messages_sender_id ON adoption_messages (sender_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS adoption_notes;
+DROP TABLE IF EXISTS adoption_status_changes;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS adoption_status_changes (
+                                                       id SERIAL PRIMARY KEY,
+                                                       adoption_request_id INT NOT NULL REFERENCES adoption_requests (id) ON DELETE CASCADE,
+                                                       actor_id INT REFERENCES users (id),
+                                                       from_status VARCHAR(20) NOT NULL DEFAULT '',
+                                                       to_status VARCHAR(20) NOT NULL,
+                                                       reason TEXT NOT NULL DEFAULT '',
+                                                       created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_adoption_status_changes_adoption_request_id ON adoption_status_changes (adoption_request_id);
+
+CREATE TABLE IF NOT EXISTS adoption_notes (
+                                              id SERIAL PRIMARY KEY,
+                                              adoption_request_id INT NOT NULL REFERENCES adoption_requests (id) ON DELETE CASCADE,
+                                              author_id INT NOT NULL REFERENCES users (id),
+                                              body TEXT NOT NULL,
+                                              created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_adoption_notes_adoption_request_id ON adoption_notes (adoption_request_id);
+
+-- existing requests start their history with the application and, if it
+-- was decided, its current status (the actor isn't known)
+INSERT INTO adoption_status_changes (adoption_request_id, actor_id, from_status, to_status, created_at)
+SELECT id, user_id, '', 'pending', created_at FROM adoption_requests;
+
+INSERT INTO adoption_status_changes (adoption_request_id, actor_id, from_status, to_status, reason, created_at)
+SELECT id, NULL, 'pending', status, status_reason, updated_at FROM adoption_requests WHERE status <> 'pending';